@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/kyuff/es"
+)
+
+var (
+	contextType  = reflect.TypeFor[context.Context]()
+	commandType  = reflect.TypeFor[Command]()
+	contentsType = reflect.TypeFor[[]es.Content]()
+	errorType    = reflect.TypeFor[error]()
+)
+
+// RegisterAggregate registers the methods of the state type S shaped as
+//
+//	func (s *S) HandleXxx(ctx context.Context, cmd XxxCommand) ([]es.Content, error)
+//
+// as executors of their command. It fails without registering anything if a method named
+// HandleXxx has another shape, or if a command name is used by more than one method or is
+// already registered. The options apply to all the commands.
+func RegisterAggregate[S es.Handler](dispatcher *Dispatcher, entityType string, opts ...RegisterOption) (err error) {
+	dispatcher.mux.Lock()
+	defer dispatcher.mux.Unlock()
+
+	defer func() {
+		msg := recover()
+		if msg != nil {
+			err = errors.Join(err, fmt.Errorf("panic in register: %v", msg))
+		}
+	}()
+
+	var (
+		typ      = reflect.TypeFor[S]()
+		newState = newInstance[S]()
+		methods  = make(map[string]reflect.Method)
+	)
+	for i := range typ.NumMethod() {
+		method := typ.Method(i)
+		if !strings.HasPrefix(method.Name, "Handle") || method.Name == "Handle" {
+			continue
+		}
+
+		cmdType, err := aggregateCommand(method)
+		if err != nil {
+			return fmt.Errorf("aggregate %s: %w", typ, err)
+		}
+
+		name := commandName(cmdType)
+		if other, ok := methods[name]; ok {
+			return fmt.Errorf("aggregate %s: command %s is handled by both %s and %s", typ, name, other.Name, method.Name)
+		}
+		if _, ok := dispatcher.executors[name]; ok {
+			return fmt.Errorf("command already registered: %s", name)
+		}
+
+		methods[name] = method
+	}
+
+	if len(methods) == 0 {
+		return fmt.Errorf("aggregate %s: no methods handling commands", typ)
+	}
+
+	for name, method := range methods {
+		dispatcher.executors[name] = newRegistration(dispatcher, name, entityType, decodeCommandType(method.Type.In(2)), newState, aggregateExecutor[S](method),
+			append(slices.Clone(opts), withTypes(method.Type.In(2), typ)),
+		)
+	}
+
+	return nil
+}
+
+// aggregateCommand returns the command type of a HandleXxx method.
+func aggregateCommand(method reflect.Method) (reflect.Type, error) {
+	var fn = method.Type
+	if fn.NumIn() != 3 || fn.In(1) != contextType || !fn.In(2).Implements(commandType) || fn.In(2).Kind() == reflect.Interface ||
+		fn.NumOut() != 2 || fn.Out(0) != contentsType || fn.Out(1) != errorType {
+		return nil, fmt.Errorf("method %s must be func(context.Context, Command) ([]es.Content, error), was %s", method.Name, fn)
+	}
+
+	return fn.In(2), nil
+}
+
+func aggregateExecutor[S es.Handler](method reflect.Method) ExecutorFunc[Command, S] {
+	var cmdType = method.Type.In(2)
+	return func(ctx context.Context, cmd Command, state S) ([]es.Content, error) {
+		if reflect.TypeOf(cmd) != cmdType {
+			return nil, fmt.Errorf("command %q is %T, expected %s", cmd.CommandName(), cmd, cmdType)
+		}
+
+		out := method.Func.Call([]reflect.Value{reflect.ValueOf(state), reflect.ValueOf(ctx), reflect.ValueOf(cmd)})
+		events, _ := out[0].Interface().([]es.Content)
+		err, _ := out[1].Interface().(error)
+		return events, err
+	}
+}
@@ -0,0 +1,132 @@
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es/storage/inmemory"
+)
+
+func TestRegisterAggregate(t *testing.T) {
+	var (
+		newDispatcher = func(t *testing.T) *commands.Dispatcher {
+			var storage = inmemory.New()
+			assert.NoError(t, storage.Register("account", TestEvent{}))
+			return commands.NewDispatcher(es.NewStore(storage))
+		}
+	)
+
+	t.Run("dispatch to methods", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+
+		// act
+		err := commands.RegisterAggregate[*TestAggregate](dispatcher, "account")
+
+		// assert
+		assert.NoError(t, err)
+		assert.NoError(t, dispatcher.Dispatch(t.Context(), "account-1", &TestPointerCommand{Value: "first"}))
+		result, err := dispatcher.DispatchResult(t.Context(), "account-1", TestCommand{Value: "second"})
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(result.Events)) {
+			assert.Equal(t, "second:first", result.Events[0].(TestEvent).Value)
+		}
+		entityType, ok := dispatcher.EntityType("TestPointerCommand")
+		assert.Truef(t, ok, "expected TestPointerCommand to be registered")
+		assert.Equal(t, "account", entityType)
+	})
+
+	t.Run("return executor errors", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+		assert.NoError(t, commands.RegisterAggregate[*TestAggregate](dispatcher, "account"))
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{Value: "fail"})
+
+		// assert
+		assert.Match(t, "executor-error", err.Error())
+	})
+
+	t.Run("decode commands", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+		assert.NoError(t, commands.RegisterAggregate[*TestAggregate](dispatcher, "account"))
+
+		// act
+		value, valueErr := dispatcher.Decode("TestCommand", []byte(`{"Value":"value"}`))
+		pointer, pointerErr := dispatcher.Decode("TestPointerCommand", []byte(`{"Value":"pointer"}`))
+
+		// assert
+		assert.NoError(t, valueErr)
+		assert.NoError(t, pointerErr)
+		assert.Equal(t, commands.Command(TestCommand{Value: "value"}), value)
+		assert.Equal(t, "pointer", pointer.(*TestPointerCommand).Value)
+	})
+
+	t.Run("fail on command of another type with the same name", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+		assert.NoError(t, commands.RegisterAggregate[*TestAggregate](dispatcher, "account"))
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestDoubleCommand{})
+
+		// assert
+		assert.Match(t, `command "TestCommand" is commands_test.TestDoubleCommand, expected commands_test.TestCommand`, err.Error())
+	})
+
+	t.Run("fail on invalid method", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+
+		// act
+		err := commands.RegisterAggregate[*TestInvalidAggregate](dispatcher, "account")
+
+		// assert
+		assert.Match(t, "method HandleTestCommand must be", err.Error())
+	})
+
+	t.Run("fail on ambiguous command names", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+
+		// act
+		err := commands.RegisterAggregate[*TestAmbiguousAggregate](dispatcher, "account")
+
+		// assert
+		assert.Match(t, "command TestCommand is handled by both HandleTestCommand and HandleTestDoubleCommand", err.Error())
+		_, ok := dispatcher.EntityType("TestCommand")
+		assert.Truef(t, !ok, "expected nothing to be registered")
+	})
+
+	t.Run("fail on registered command without registering any", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+		_ = commands.RegisterFunc(dispatcher, "other", func(ctx context.Context, cmd *TestPointerCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		})
+
+		// act
+		err := commands.RegisterAggregate[*TestAggregate](dispatcher, "account")
+
+		// assert
+		assert.Match(t, "command already registered: TestPointerCommand", err.Error())
+		_, ok := dispatcher.EntityType("TestCommand")
+		assert.Truef(t, !ok, "expected TestCommand not to be registered")
+	})
+
+	t.Run("fail without methods", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+
+		// act
+		err := commands.RegisterAggregate[*TestProcessState](dispatcher, "account")
+
+		// assert
+		assert.Match(t, "no methods handling commands", err.Error())
+	})
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+// ForbiddenError is returned when an actor is not allowed to execute a command.
+type ForbiddenError struct {
+	ActorID     string
+	CommandName string
+	Reason      string
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("actor %q is not allowed to execute %s: %s", e.ActorID, e.CommandName, e.Reason)
+}
+
+func (e *ForbiddenError) Unwrap() error {
+	return ErrForbidden
+}
+
+func (e *ForbiddenError) ErrorKind() string {
+	return "forbidden"
+}
+
+type Actor struct {
+	ID    string
+	Roles []string
+}
+
+type actorKey struct{}
+
+func ContextWithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
+
+type Policy interface {
+	Allow(ctx context.Context, actor Actor, commandName, entityType, entityID string) error
+}
+
+type PolicyFunc func(ctx context.Context, actor Actor, commandName, entityType, entityID string) error
+
+func (fn PolicyFunc) Allow(ctx context.Context, actor Actor, commandName, entityType, entityID string) error {
+	return fn(ctx, actor, commandName, entityType, entityID)
+}
+
+type AuthorizeOption func(cfg *authorizeConfig)
+
+// AuthorizeActor sets how the Actor is found for a command. Defaults to ActorFromContext.
+func AuthorizeActor(fn func(ctx context.Context, command Command) (Actor, bool)) AuthorizeOption {
+	return func(cfg *authorizeConfig) {
+		cfg.actor = fn
+	}
+}
+
+type authorizeConfig struct {
+	actor func(ctx context.Context, command Command) (Actor, bool)
+}
+
+// Authorize asks the Policy if the Actor is allowed to execute the command before calling next.
+// Commands without an Actor are rejected.
+func Authorize(policy Policy, opts ...AuthorizeOption) MiddlewareFunc {
+	var cfg = &authorizeConfig{
+		actor: func(ctx context.Context, command Command) (Actor, bool) {
+			return ActorFromContext(ctx)
+		},
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next func(ctx context.Context, command Command) error) func(ctx context.Context, command Command) error {
+		return func(ctx context.Context, command Command) error {
+			actor, ok := cfg.actor(ctx, command)
+			if !ok {
+				return &ForbiddenError{
+					CommandName: command.CommandName(),
+					Reason:      "no actor",
+				}
+			}
+
+			var entityType, entityID string
+			if exec, ok := executionFrom(ctx); ok {
+				entityType, entityID = exec.entityType, exec.entityID
+			}
+
+			err := policy.Allow(ctx, actor, command.CommandName(), entityType, entityID)
+			if err != nil {
+				return err
+			}
+
+			return next(ctx, command)
+		}
+	}
+}
+
+// RoleTable is a Policy that allows commands by the roles of the Actor.
+// Roles map to the command names they may execute, where "*" allows all commands.
+type RoleTable struct {
+	Roles map[string][]string `json:"roles" yaml:"roles"`
+}
+
+// LoadRoleTable reads a RoleTable using unmarshal, such as json.Unmarshal or yaml.Unmarshal:
+//
+//	roles:
+//	  admin: ["*"]
+//	  teller: [OpenAccount, Deposit]
+func LoadRoleTable(data []byte, unmarshal func(data []byte, v any) error) (*RoleTable, error) {
+	var table RoleTable
+	err := unmarshal(data, &table)
+	if err != nil {
+		return nil, fmt.Errorf("load role table: %w", err)
+	}
+
+	if len(table.Roles) == 0 {
+		return nil, errors.New("load role table: no roles")
+	}
+
+	return &table, nil
+}
+
+func (t *RoleTable) Allow(ctx context.Context, actor Actor, commandName, entityType, entityID string) error {
+	for _, role := range actor.Roles {
+		names := t.Roles[role]
+		if slices.Contains(names, "*") || slices.Contains(names, commandName) {
+			return nil
+		}
+	}
+
+	return &ForbiddenError{
+		ActorID:     actor.ID,
+		CommandName: commandName,
+		Reason:      "no role allows the command",
+	}
+}
@@ -0,0 +1,179 @@
+package commands_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestAuthorize(t *testing.T) {
+	var (
+		next = func(ctx context.Context, command commands.Command) error {
+			return nil
+		}
+		allow = func(ctx context.Context, actor commands.Actor, commandName, entityType, entityID string) error {
+			return nil
+		}
+	)
+
+	t.Run("allow actor from context", func(t *testing.T) {
+		// arrange
+		var (
+			policy = &PolicyMock{AllowFunc: allow}
+			ctx    = commands.ContextWithActor(t.Context(), commands.Actor{ID: "actor-1"})
+			sut    = commands.Authorize(policy).Intercept(next)
+		)
+
+		// act
+		err := sut(ctx, TestCommand{})
+
+		// assert
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(policy.AllowCalls())) {
+			assert.Equal(t, "actor-1", policy.AllowCalls()[0].Actor.ID)
+			assert.Equal(t, "TestCommand", policy.AllowCalls()[0].CommandName)
+		}
+	})
+
+	t.Run("fail without actor", func(t *testing.T) {
+		// arrange
+		var (
+			policy = &PolicyMock{AllowFunc: allow}
+			sut    = commands.Authorize(policy).Intercept(next)
+		)
+
+		// act
+		err := sut(t.Context(), TestCommand{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrForbidden), "expected ErrForbidden, got %v", err)
+		assert.Equal(t, 0, len(policy.AllowCalls()))
+	})
+
+	t.Run("fail when policy denies", func(t *testing.T) {
+		// arrange
+		var (
+			nextCalled = false
+			policy     = &PolicyMock{AllowFunc: func(ctx context.Context, actor commands.Actor, commandName, entityType, entityID string) error {
+				return &commands.ForbiddenError{ActorID: actor.ID, CommandName: commandName, Reason: "denied"}
+			}}
+			ctx = commands.ContextWithActor(t.Context(), commands.Actor{ID: "actor-1"})
+			sut = commands.Authorize(policy).Intercept(func(ctx context.Context, command commands.Command) error {
+				nextCalled = true
+				return nil
+			})
+		)
+
+		// act
+		err := sut(ctx, TestCommand{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrForbidden), "expected ErrForbidden, got %v", err)
+		assert.Truef(t, !nextCalled, "expected next not to be called")
+	})
+
+	t.Run("read actor from command", func(t *testing.T) {
+		// arrange
+		var (
+			policy = &PolicyMock{AllowFunc: allow}
+			sut    = commands.Authorize(policy, commands.AuthorizeActor(func(ctx context.Context, command commands.Command) (commands.Actor, bool) {
+				return commands.Actor{ID: command.(TestCommand).Value}, true
+			})).Intercept(next)
+		)
+
+		// act
+		err := sut(t.Context(), TestCommand{Value: "actor-2"})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "actor-2", policy.AllowCalls()[0].Actor.ID)
+	})
+
+	t.Run("pass entity to policy", func(t *testing.T) {
+		// arrange
+		var (
+			policy     = &PolicyMock{AllowFunc: allow}
+			store      = &StoreMock{}
+			dispatcher = commands.New(store, commands.WithMiddlewares(commands.Authorize(policy)))
+			ctx        = commands.ContextWithActor(t.Context(), commands.Actor{ID: "actor-1"})
+		)
+
+		store.OpenFunc = func(ctx context.Context, entityType string, entityID string) es.Stream {
+			return &StreamMock{
+				ProjectFunc: func(handler es.Handler) error {
+					return nil
+				},
+				CloseFunc: func() error {
+					return nil
+				},
+			}
+		}
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		})
+
+		// act
+		err := dispatcher.Dispatch(ctx, "account-1", TestCommand{})
+
+		// assert
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(policy.AllowCalls())) {
+			assert.Equal(t, "account", policy.AllowCalls()[0].EntityType)
+			assert.Equal(t, "account-1", policy.AllowCalls()[0].EntityID)
+		}
+	})
+}
+
+func TestRoleTable(t *testing.T) {
+	var data = []byte(`{
+		"roles": {
+			"admin": ["*"],
+			"teller": ["TestCommand"]
+		}
+	}`)
+
+	var testCases = []struct {
+		name      string
+		roles     []string
+		command   string
+		expectErr bool
+	}{
+		{name: "allow listed command", roles: []string{"teller"}, command: "TestCommand"},
+		{name: "allow wildcard", roles: []string{"admin"}, command: "TestPointerCommand"},
+		{name: "allow any role", roles: []string{"unknown", "teller"}, command: "TestCommand"},
+		{name: "deny unlisted command", roles: []string{"teller"}, command: "TestPointerCommand", expectErr: true},
+		{name: "deny without roles", roles: nil, command: "TestCommand", expectErr: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			table, err := commands.LoadRoleTable(data, json.Unmarshal)
+			assert.NoError(t, err)
+
+			// act
+			err = table.Allow(t.Context(), commands.Actor{ID: "actor-1", Roles: tt.roles}, tt.command, "account", "account-1")
+
+			// assert
+			if tt.expectErr {
+				assert.Truef(t, errors.Is(err, commands.ErrForbidden), "expected ErrForbidden, got %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("fail loading invalid table", func(t *testing.T) {
+		// act
+		_, err := commands.LoadRoleTable([]byte(`{"roles": {}}`), json.Unmarshal)
+
+		// assert
+		assert.Error(t, err)
+	})
+}
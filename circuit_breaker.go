@@ -0,0 +1,325 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/kyuff/es"
+)
+
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitOpenError is returned by streams opened while the circuit of the entity type is open.
+type CircuitOpenError struct {
+	EntityType string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit open for entity type %q, retry after %s", e.EntityType, e.RetryAfter)
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+func (e *CircuitOpenError) ErrorKind() string {
+	return "circuit_open"
+}
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+type CircuitBreakerOption func(cfg *circuitBreakerConfig)
+
+// CircuitFailureThreshold sets the number of consecutive failures that opens the circuit. Defaults to 5.
+func CircuitFailureThreshold(failures int) CircuitBreakerOption {
+	return func(cfg *circuitBreakerConfig) {
+		cfg.threshold = failures
+	}
+}
+
+// CircuitOpenTimeout sets how long the circuit stays open before a single probe is let through. Defaults to 30 seconds.
+func CircuitOpenTimeout(timeout time.Duration) CircuitBreakerOption {
+	return func(cfg *circuitBreakerConfig) {
+		cfg.openTimeout = timeout
+	}
+}
+
+// CircuitIsFailure decides which stream errors count as failures of the store.
+// By default all errors except context.Canceled are counted.
+func CircuitIsFailure(fn func(err error) bool) CircuitBreakerOption {
+	return func(cfg *circuitBreakerConfig) {
+		cfg.isFailure = fn
+	}
+}
+
+func CircuitOnStateChange(fn func(entityType string, from, to CircuitState)) CircuitBreakerOption {
+	return func(cfg *circuitBreakerConfig) {
+		cfg.onStateChange = fn
+	}
+}
+
+func CircuitClock(now func() time.Time) CircuitBreakerOption {
+	return func(cfg *circuitBreakerConfig) {
+		cfg.now = now
+	}
+}
+
+type circuitBreakerConfig struct {
+	threshold     int
+	openTimeout   time.Duration
+	isFailure     func(err error) bool
+	onStateChange func(entityType string, from, to CircuitState)
+	now           func() time.Time
+}
+
+// NewCircuitBreaker decorates a Store with a circuit breaker per entity type.
+// When the circuit is open, streams fail fast with a CircuitOpenError without using the store.
+func NewCircuitBreaker(store Store, opts ...CircuitBreakerOption) *CircuitBreaker {
+	var cfg = &circuitBreakerConfig{
+		threshold:   5,
+		openTimeout: 30 * time.Second,
+		isFailure: func(err error) bool {
+			return !errors.Is(err, context.Canceled)
+		},
+		onStateChange: func(entityType string, from, to CircuitState) {},
+		now:           time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return &CircuitBreaker{
+		store:    store,
+		cfg:      cfg,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+type CircuitBreaker struct {
+	store    Store
+	cfg      *circuitBreakerConfig
+	mux      sync.Mutex
+	circuits map[string]*circuit
+}
+
+func (cb *CircuitBreaker) Open(ctx context.Context, entityType string, entityID string) es.Stream {
+	var c = cb.circuit(entityType)
+	if retryAfter, ok := c.allow(cb.cfg); !ok {
+		return &errorStream{err: &CircuitOpenError{
+			EntityType: entityType,
+			RetryAfter: retryAfter,
+		}}
+	}
+
+	return &circuitStream{
+		Stream:  cb.store.Open(ctx, entityType, entityID),
+		cfg:     cb.cfg,
+		circuit: c,
+	}
+}
+
+// State of the circuit for the entity type.
+func (cb *CircuitBreaker) State(entityType string) CircuitState {
+	var c = cb.circuit(entityType)
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.state
+}
+
+func (cb *CircuitBreaker) circuit(entityType string) *circuit {
+	cb.mux.Lock()
+	defer cb.mux.Unlock()
+
+	c, ok := cb.circuits[entityType]
+	if !ok {
+		c = &circuit{entityType: entityType}
+		cb.circuits[entityType] = c
+	}
+
+	return c
+}
+
+type circuit struct {
+	entityType string
+	mux        sync.Mutex
+	state      CircuitState
+	failures   int
+	openedAt   time.Time
+	probing    bool
+}
+
+func (c *circuit) allow(cfg *circuitBreakerConfig) (time.Duration, bool) {
+	c.mux.Lock()
+	var from = c.state
+	switch c.state {
+	case CircuitOpen:
+		elapsed := cfg.now().Sub(c.openedAt)
+		if elapsed < cfg.openTimeout {
+			c.mux.Unlock()
+			return cfg.openTimeout - elapsed, false
+		}
+		c.state = CircuitHalfOpen
+		c.probing = true
+	case CircuitHalfOpen:
+		if c.probing {
+			c.mux.Unlock()
+			return 0, false
+		}
+		c.probing = true
+	}
+	var to = c.state
+	c.mux.Unlock()
+
+	if from != to {
+		cfg.onStateChange(c.entityType, from, to)
+	}
+
+	return 0, true
+}
+
+// release a probe that neither failed nor succeeded, letting the next stream probe instead.
+func (c *circuit) release() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.probing = false
+}
+
+func (c *circuit) record(cfg *circuitBreakerConfig, failed bool) {
+	c.mux.Lock()
+	var from = c.state
+	switch c.state {
+	case CircuitClosed:
+		if !failed {
+			c.failures = 0
+			break
+		}
+		c.failures++
+		if c.failures >= cfg.threshold {
+			c.state = CircuitOpen
+			c.openedAt = cfg.now()
+		}
+	case CircuitHalfOpen:
+		c.probing = false
+		c.failures = 0
+		if failed {
+			c.state = CircuitOpen
+			c.openedAt = cfg.now()
+		} else {
+			c.state = CircuitClosed
+		}
+	}
+	var to = c.state
+	c.mux.Unlock()
+
+	if from != to {
+		cfg.onStateChange(c.entityType, from, to)
+	}
+}
+
+type circuitStream struct {
+	es.Stream
+	cfg      *circuitBreakerConfig
+	circuit  *circuit
+	recorded bool
+	// errored is set by errors that are not counted as failures.
+	errored bool
+}
+
+func (s *circuitStream) Project(handler es.Handler) error {
+	return s.observe(s.Stream.Project(handler))
+}
+
+func (s *circuitStream) Write(events ...es.Content) error {
+	return s.observe(s.Stream.Write(events...))
+}
+
+func (s *circuitStream) All() iter.Seq2[es.Event, error] {
+	return func(yield func(es.Event, error) bool) {
+		for event, err := range s.Stream.All() {
+			if !yield(event, s.observe(err)) {
+				return
+			}
+		}
+	}
+}
+
+func (s *circuitStream) Close() error {
+	if !s.recorded {
+		s.recorded = true
+		if s.errored {
+			s.circuit.release()
+		} else {
+			s.circuit.record(s.cfg, false)
+		}
+	}
+
+	return s.Stream.Close()
+}
+
+func (s *circuitStream) observe(err error) error {
+	if err == nil || s.recorded {
+		return err
+	}
+
+	if s.cfg.isFailure(err) {
+		s.recorded = true
+		s.circuit.record(s.cfg, true)
+	} else {
+		s.errored = true
+	}
+
+	return err
+}
+
+type errorStream struct {
+	err error
+}
+
+func (s *errorStream) Project(handler es.Handler) error {
+	return s.err
+}
+
+func (s *errorStream) All() iter.Seq2[es.Event, error] {
+	return func(yield func(es.Event, error) bool) {
+		yield(es.Event{}, s.err)
+	}
+}
+
+func (s *errorStream) Write(events ...es.Content) error {
+	return s.err
+}
+
+func (s *errorStream) Position() int64 {
+	return 0
+}
+
+func (s *errorStream) Close() error {
+	return nil
+}
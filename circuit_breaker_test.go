@@ -0,0 +1,277 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	type transition struct {
+		entityType string
+		from, to   commands.CircuitState
+	}
+
+	var (
+		newStore = func(projectErr *error) *StoreMock {
+			return &StoreMock{
+				OpenFunc: func(ctx context.Context, entityType string, entityID string) es.Stream {
+					return &StreamMock{
+						ProjectFunc: func(handler es.Handler) error {
+							return *projectErr
+						},
+						WriteFunc: func(events ...es.Content) error {
+							return nil
+						},
+						CloseFunc: func() error {
+							return nil
+						},
+					}
+				},
+			}
+		}
+		newClock = func() (func() time.Time, func(d time.Duration)) {
+			var now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			return func() time.Time {
+					return now
+				}, func(d time.Duration) {
+					now = now.Add(d)
+				}
+		}
+		project = func(store commands.Store, entityType string) error {
+			stream := store.Open(context.Background(), entityType, "entity-1")
+			defer func() {
+				_ = stream.Close()
+			}()
+			return stream.Project(&StateMock{})
+		}
+		storeErr = errors.New("store unavailable")
+	)
+
+	t.Run("open after consecutive failures", func(t *testing.T) {
+		// arrange
+		var (
+			err         = storeErr
+			store       = newStore(&err)
+			transitions []transition
+			sut         = commands.NewCircuitBreaker(store,
+				commands.CircuitFailureThreshold(3),
+				commands.CircuitOnStateChange(func(entityType string, from, to commands.CircuitState) {
+					transitions = append(transitions, transition{entityType, from, to})
+				}),
+			)
+		)
+
+		// act
+		for range 3 {
+			assert.Error(t, project(sut, "account"))
+		}
+		got := project(sut, "account")
+
+		// assert
+		assert.Truef(t, errors.Is(got, commands.ErrCircuitOpen), "expected ErrCircuitOpen, got %v", got)
+		assert.Equal(t, 3, len(store.OpenCalls()))
+		assert.Equal(t, commands.CircuitOpen, sut.State("account"))
+		assert.EqualSlice(t, []transition{{"account", commands.CircuitClosed, commands.CircuitOpen}}, transitions)
+	})
+
+	t.Run("keep circuits per entity type", func(t *testing.T) {
+		// arrange
+		var (
+			err   = storeErr
+			store = newStore(&err)
+			sut   = commands.NewCircuitBreaker(store, commands.CircuitFailureThreshold(1))
+		)
+
+		// act
+		_ = project(sut, "account")
+
+		// assert
+		assert.Equal(t, commands.CircuitOpen, sut.State("account"))
+		assert.Equal(t, commands.CircuitClosed, sut.State("customer"))
+	})
+
+	t.Run("reset failures on success", func(t *testing.T) {
+		// arrange
+		var (
+			err   = storeErr
+			store = newStore(&err)
+			sut   = commands.NewCircuitBreaker(store, commands.CircuitFailureThreshold(2))
+		)
+
+		// act
+		_ = project(sut, "account")
+		err = nil
+		_ = project(sut, "account")
+		err = storeErr
+		_ = project(sut, "account")
+
+		// assert
+		assert.Equal(t, commands.CircuitClosed, sut.State("account"))
+	})
+
+	t.Run("close after successful probe", func(t *testing.T) {
+		// arrange
+		var (
+			err          = storeErr
+			store        = newStore(&err)
+			now, advance = newClock()
+			transitions  []transition
+			sut          = commands.NewCircuitBreaker(store,
+				commands.CircuitFailureThreshold(1),
+				commands.CircuitOpenTimeout(time.Second),
+				commands.CircuitClock(now),
+				commands.CircuitOnStateChange(func(entityType string, from, to commands.CircuitState) {
+					transitions = append(transitions, transition{entityType, from, to})
+				}),
+			)
+		)
+
+		_ = project(sut, "account")
+		err = nil
+		advance(time.Second)
+
+		// act
+		got := project(sut, "account")
+
+		// assert
+		assert.NoError(t, got)
+		assert.Equal(t, commands.CircuitClosed, sut.State("account"))
+		assert.EqualSlice(t, []transition{
+			{"account", commands.CircuitClosed, commands.CircuitOpen},
+			{"account", commands.CircuitOpen, commands.CircuitHalfOpen},
+			{"account", commands.CircuitHalfOpen, commands.CircuitClosed},
+		}, transitions)
+	})
+
+	t.Run("reopen after failed probe", func(t *testing.T) {
+		// arrange
+		var (
+			err          = storeErr
+			store        = newStore(&err)
+			now, advance = newClock()
+			sut          = commands.NewCircuitBreaker(store,
+				commands.CircuitFailureThreshold(1),
+				commands.CircuitOpenTimeout(time.Second),
+				commands.CircuitClock(now),
+			)
+		)
+
+		_ = project(sut, "account")
+		advance(time.Second)
+
+		// act
+		probe := project(sut, "account")
+		got := project(sut, "account")
+
+		// assert
+		assert.Truef(t, errors.Is(probe, storeErr), "expected store error, got %v", probe)
+		var openErr *commands.CircuitOpenError
+		if assert.Truef(t, errors.As(got, &openErr), "expected CircuitOpenError, got %v", got) {
+			assert.Equal(t, "account", openErr.EntityType)
+			assert.Equal(t, time.Second, openErr.RetryAfter)
+		}
+	})
+
+	t.Run("stay half-open after probe with ignored error", func(t *testing.T) {
+		// arrange
+		var (
+			err          = storeErr
+			store        = newStore(&err)
+			now, advance = newClock()
+			sut          = commands.NewCircuitBreaker(store,
+				commands.CircuitFailureThreshold(1),
+				commands.CircuitOpenTimeout(time.Second),
+				commands.CircuitClock(now),
+			)
+		)
+
+		_ = project(sut, "account")
+		advance(time.Second)
+		err = context.Canceled
+
+		// act
+		probe := project(sut, "account")
+
+		// assert
+		assert.Truef(t, errors.Is(probe, context.Canceled), "expected canceled, got %v", probe)
+		assert.Equal(t, commands.CircuitHalfOpen, sut.State("account"))
+		err = nil
+		assert.NoError(t, project(sut, "account"))
+		assert.Equal(t, commands.CircuitClosed, sut.State("account"))
+	})
+
+	t.Run("allow a single probe when half-open", func(t *testing.T) {
+		// arrange
+		var (
+			err          = storeErr
+			store        = newStore(&err)
+			now, advance = newClock()
+			sut          = commands.NewCircuitBreaker(store,
+				commands.CircuitFailureThreshold(1),
+				commands.CircuitOpenTimeout(time.Second),
+				commands.CircuitClock(now),
+			)
+		)
+
+		_ = project(sut, "account")
+		advance(time.Second)
+
+		// act
+		probe := sut.Open(t.Context(), "account", "entity-1")
+		got := project(sut, "account")
+		_ = probe.Close()
+
+		// assert
+		assert.Truef(t, errors.Is(got, commands.ErrCircuitOpen), "expected ErrCircuitOpen, got %v", got)
+		assert.Equal(t, commands.CircuitClosed, sut.State("account"))
+	})
+
+	t.Run("ignore executor errors", func(t *testing.T) {
+		// arrange
+		var (
+			err        error
+			store      = newStore(&err)
+			sut        = commands.NewCircuitBreaker(store, commands.CircuitFailureThreshold(1))
+			dispatcher = commands.NewDispatcher(sut)
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, errors.New("executor-error")
+		})
+
+		// act
+		got := dispatcher.Dispatch(t.Context(), "entity-1", TestCommand{})
+
+		// assert
+		assert.Error(t, got)
+		assert.Equal(t, commands.CircuitClosed, sut.State("account"))
+	})
+
+	t.Run("fail fast in dispatcher", func(t *testing.T) {
+		// arrange
+		var (
+			err        = storeErr
+			store      = newStore(&err)
+			sut        = commands.NewCircuitBreaker(store, commands.CircuitFailureThreshold(1))
+			dispatcher = commands.NewDispatcher(sut)
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		})
+		_ = dispatcher.Dispatch(t.Context(), "entity-1", TestCommand{})
+
+		// act
+		got := dispatcher.Dispatch(t.Context(), "entity-1", TestCommand{})
+
+		// assert
+		assert.Truef(t, errors.Is(got, commands.ErrCircuitOpen), "expected ErrCircuitOpen, got %v", got)
+		assert.Equal(t, 1, len(store.OpenCalls()))
+	})
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const entityDirective = "//escommands:entity "
+
+type command struct {
+	typ  types.Type
+	Name string
+	Type string
+	// Register is the func the Executor is registered with.
+	Register string
+	Executor string
+	Entity   string
+	Proto    bool
+}
+
+type spec struct {
+	Package  string
+	Client   string
+	Proto    bool
+	Commands []command
+}
+
+func run(dir, out, client string) error {
+	pkg, err := load(dir, out)
+	if err != nil {
+		return err
+	}
+
+	s, err := scan(pkg, client)
+	if err != nil {
+		return err
+	}
+
+	src, err := generate(s)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, out), src, 0o644)
+}
+
+// load and type check the package in dir, leaving out the previously generated file.
+func load(dir, out string) (*packages.Package, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		// the dependencies are loaded to type check the imports from source, as export data
+		// is not available for all versions of the toolchain.
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+		Dir:  dir,
+		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+			var mode = parser.ParseComments
+			if filename == filepath.Join(dir, out) {
+				mode = parser.PackageClauseOnly
+			}
+			return parser.ParseFile(fset, filename, src, mode)
+		},
+	}, ".")
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected a single package in %s, got %d", dir, len(pkgs))
+	}
+
+	var errs []error
+	for _, pkgErr := range pkgs[0].Errors {
+		errs = append(errs, pkgErr)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return pkgs[0], nil
+}
+
+// scan the package for commands and their executors, which are either funcs or types
+// implementing commands.Executor.
+func scan(pkg *packages.Package, client string) (spec, error) {
+	var (
+		scope    = pkg.Types.Scope()
+		commands = make(map[*types.TypeName]*command)
+		docs     = typeDocs(pkg.Syntax, pkg.TypesInfo)
+	)
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || obj.IsAlias() {
+			continue
+		}
+
+		if typ, ok := commandType(obj.Type()); ok {
+			commands[obj] = &command{
+				typ:   typ,
+				Type:  types.TypeString(typ, types.RelativeTo(pkg.Types)),
+				Proto: hasMethod(typ, "ProtoReflect"),
+			}
+		}
+	}
+
+	var (
+		errs []error
+		add  = func(name string, sig *types.Signature, doc *ast.CommentGroup, register, executor string) {
+			cmdType, state, ok := executorTypes(sig)
+			if !ok {
+				return
+			}
+
+			cmd, ok := commands[cmdType.Obj()]
+			if !ok {
+				return
+			}
+			if cmd.Executor != "" {
+				errs = append(errs, fmt.Errorf("command %s has executors %s and %s", cmdType.Obj().Name(), cmd.Executor, name))
+				return
+			}
+
+			cmd.Type = types.TypeString(sig.Params().At(1).Type(), types.RelativeTo(pkg.Types))
+			cmd.Register = register
+			cmd.Executor = executor
+			cmd.Entity = entityType(doc, state)
+		}
+	)
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil {
+				continue
+			}
+
+			obj := pkg.TypesInfo.Defs[fn.Name].(*types.Func)
+			add(fn.Name.Name, obj.Type().(*types.Signature), fn.Doc, "commands.RegisterFunc", fn.Name.Name)
+		}
+	}
+
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || obj.IsAlias() {
+			continue
+		}
+
+		// the method set of the pointer holds the Execute methods of both receivers.
+		sig, ok := executeMethod(types.NewPointer(obj.Type()))
+		if !ok {
+			continue
+		}
+
+		var (
+			params   = sig.Params()
+			register = fmt.Sprintf("commands.Register[%s, %s]",
+				types.TypeString(params.At(1).Type(), types.RelativeTo(pkg.Types)),
+				types.TypeString(params.At(2).Type(), types.RelativeTo(pkg.Types)),
+			)
+		)
+		add(name, sig, docs[obj], register, "new("+name+")")
+	}
+
+	var s = spec{
+		Package: pkg.Name,
+		Client:  client,
+	}
+	for obj, cmd := range commands {
+		if cmd.Executor == "" {
+			errs = append(errs, fmt.Errorf("command %s has no executor", obj.Name()))
+			continue
+		}
+
+		cmd.Name = obj.Name()
+		s.Proto = s.Proto || cmd.Proto
+		s.Commands = append(s.Commands, *cmd)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return spec{}, err
+	}
+
+	slices.SortFunc(s.Commands, func(a, b command) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return s, nil
+}
+
+// typeDocs returns the doc comments of the types declared in the files.
+func typeDocs(files []*ast.File, info *types.Info) map[types.Object]*ast.CommentGroup {
+	var docs = make(map[types.Object]*ast.CommentGroup)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				doc := typeSpec.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				docs[info.Defs[typeSpec.Name]] = doc
+			}
+		}
+	}
+
+	return docs
+}
+
+// commandType returns the type implementing Command, which is either the named type or a pointer to it.
+func commandType(typ types.Type) (types.Type, bool) {
+	if _, ok := typ.Underlying().(*types.Interface); ok {
+		return nil, false
+	}
+
+	for _, t := range []types.Type{typ, types.NewPointer(typ)} {
+		if isCommand(t) {
+			return t, true
+		}
+	}
+
+	return nil, false
+}
+
+// isCommand reports whether the method set of the type has CommandName.
+func isCommand(typ types.Type) bool {
+	sel := types.NewMethodSet(typ).Lookup(nil, "CommandName")
+	if sel == nil {
+		return false
+	}
+
+	sig := sel.Type().(*types.Signature)
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 && types.Identical(sig.Results().At(0).Type(), types.Typ[types.String])
+}
+
+func hasMethod(typ types.Type, name string) bool {
+	return types.NewMethodSet(typ).Lookup(nil, name) != nil
+}
+
+// executeMethod returns the signature of the Execute method in the method set of the type.
+func executeMethod(typ types.Type) (*types.Signature, bool) {
+	if _, ok := typ.Underlying().(*types.Interface); ok {
+		return nil, false
+	}
+
+	sel := types.NewMethodSet(typ).Lookup(nil, "Execute")
+	if sel == nil {
+		return nil, false
+	}
+
+	sig := sel.Type().(*types.Signature)
+	_, _, ok := executorTypes(sig)
+	return sig, ok
+}
+
+// executorTypes returns the command and state of a func(context.Context, C, S) ([]es.Content, error).
+// Like commands.Register, C may be the command type or a pointer to it, as long as it implements Command.
+func executorTypes(sig *types.Signature) (*types.Named, types.Type, bool) {
+	if sig.TypeParams().Len() > 0 || sig.Params().Len() != 3 || sig.Results().Len() != 2 {
+		return nil, nil, false
+	}
+
+	var (
+		params  = sig.Params()
+		results = sig.Results()
+	)
+	if !isNamed(params.At(0).Type(), "context", "Context") ||
+		!isEventSlice(results.At(0).Type()) ||
+		!isNamed(results.At(1).Type(), "", "error") ||
+		!hasMethod(params.At(2).Type(), "Handle") ||
+		!isCommand(params.At(1).Type()) {
+		return nil, nil, false
+	}
+
+	var cmd = params.At(1).Type()
+	if ptr, ok := cmd.(*types.Pointer); ok {
+		cmd = ptr.Elem()
+	}
+	named, ok := cmd.(*types.Named)
+	if !ok {
+		return nil, nil, false
+	}
+
+	return named, params.At(2).Type(), true
+}
+
+func isNamed(typ types.Type, pkg, name string) bool {
+	named, ok := typ.(*types.Named)
+	if !ok || named.Obj().Name() != name {
+		return false
+	}
+	if named.Obj().Pkg() == nil {
+		return pkg == ""
+	}
+
+	return named.Obj().Pkg().Path() == pkg
+}
+
+func isEventSlice(typ types.Type) bool {
+	slice, ok := typ.(*types.Slice)
+	return ok && isNamed(slice.Elem(), "github.com/kyuff/es", "Content")
+}
+
+func entityType(doc *ast.CommentGroup, state types.Type) string {
+	if doc != nil {
+		for _, comment := range doc.List {
+			if entity, ok := strings.CutPrefix(comment.Text, entityDirective); ok {
+				return strings.TrimSpace(entity)
+			}
+		}
+	}
+
+	if ptr, ok := state.(*types.Pointer); ok {
+		state = ptr.Elem()
+	}
+	if named, ok := state.(*types.Named); ok {
+		return strings.ToLower(named.Obj().Name())
+	}
+
+	return strings.ToLower(types.TypeString(state, nil))
+}
+
+var tmpl = template.Must(template.New("commands").Parse(`// Code generated by es-commands-gen. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"context"
+
+	commands "github.com/kyuff/es-commands"
+{{- if .Proto }}
+	"github.com/kyuff/es-commands/protocodec"
+{{- end }}
+)
+
+// RegisterCommands registers the executors of the package with the Dispatcher.
+func RegisterCommands(dispatcher *commands.Dispatcher, opts ...commands.RegisterOption) error {
+{{- range .Commands }}
+{{- if .Proto }}
+	if err := {{ .Register }}(dispatcher, {{ printf "%q" .Entity }}, {{ .Executor }}, append([]commands.RegisterOption{commands.WithCommandCodec(protocodec.New())}, opts...)...); err != nil {
+		return err
+	}
+{{- else }}
+	if err := {{ .Register }}(dispatcher, {{ printf "%q" .Entity }}, {{ .Executor }}, opts...); err != nil {
+		return err
+	}
+{{- end }}
+{{- end }}
+
+	return nil
+}
+
+// {{ .Client }} dispatches the commands of the package.
+type {{ .Client }} struct {
+	bus commands.CommandBus
+}
+
+func New{{ .Client }}(bus commands.CommandBus) *{{ .Client }} {
+	return &{{ .Client }}{bus: bus}
+}
+{{ range .Commands }}
+func (c *{{ $.Client }}) {{ .Name }}(ctx context.Context, entityID string, cmd {{ .Type }}) error {
+	return c.bus.Dispatch(ctx, entityID, cmd)
+}
+{{ end }}`))
+
+func generate(s spec) ([]byte, error) {
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/cmd/es-commands-gen/testdata/account"
+	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es/storage/inmemory"
+)
+
+func TestGenerate(t *testing.T) {
+	var (
+		generateDir = func(t *testing.T, dir string) (string, error) {
+			pkg, err := load(dir, "commands_gen.go")
+			if err != nil {
+				return "", err
+			}
+
+			s, err := scan(pkg, "Client")
+			if err != nil {
+				return "", err
+			}
+
+			src, err := generate(s)
+			return string(src), err
+		}
+		golden = func(t *testing.T, dir string) string {
+			src, err := os.ReadFile(filepath.Join(dir, "commands_gen.go"))
+			assert.NoError(t, err)
+			return string(src)
+		}
+	)
+
+	t.Run("generate registration and client", func(t *testing.T) {
+		// act
+		got, err := generateDir(t, "testdata/account")
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, golden(t, "testdata/account"), got)
+	})
+
+	t.Run("generate codec registration for proto commands", func(t *testing.T) {
+		// act
+		got, err := generateDir(t, "testdata/proto")
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, golden(t, "testdata/proto"), got)
+		assert.Match(t, `commands.WithCommandCodec\(protocodec.New\(\)\)`, got)
+	})
+
+	t.Run("fail on command without executor", func(t *testing.T) {
+		// act
+		_, err := generateDir(t, "testdata/unregistered")
+
+		// assert
+		assert.Match(t, "command OpenAccount has no executor", err.Error())
+	})
+
+	t.Run("fail on command with two executors", func(t *testing.T) {
+		// act
+		_, err := generateDir(t, "testdata/duplicate")
+
+		// assert
+		assert.Match(t, "command OpenAccount has executors OpenAccountExecutor and OpenAccountAgain", err.Error())
+	})
+
+	t.Run("dispatch with generated client", func(t *testing.T) {
+		// arrange
+		var (
+			storage    = inmemory.New()
+			dispatcher = commands.NewDispatcher(es.NewStore(storage))
+			client     = account.NewClient(dispatcher)
+		)
+		assert.NoError(t, storage.Register("account", account.AccountOpened{}))
+		assert.NoError(t, account.RegisterCommands(dispatcher))
+
+		// act
+		err := client.OpenAccount(t.Context(), "account-1", account.OpenAccount{Owner: "owner-1"})
+
+		// assert
+		assert.NoError(t, err)
+		assert.NoError(t, client.CloseAccount(t.Context(), "account-1", &account.CloseAccount{}))
+		assert.NoError(t, client.RenameAccount(t.Context(), "account-1", &account.RenameAccount{Owner: "owner-2"}))
+		entityType, _ := dispatcher.EntityType("AuditAccount")
+		assert.Equal(t, "audit_log", entityType)
+	})
+}
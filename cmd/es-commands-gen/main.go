@@ -0,0 +1,43 @@
+// Command es-commands-gen generates the registration of the executors in a package,
+// and a typed client with a method per command.
+//
+// Executors are functions shaped as
+//
+//	func(ctx context.Context, cmd C, state S) ([]es.Content, error)
+//
+// or types implementing commands.Executor with such an Execute method, where C is a command
+// of the package or a pointer to it. Types are registered with their zero value. Executors are
+// registered with the entity type given by an //escommands:entity directive in their doc
+// comment, or the lower cased name of S. Commands without an executor fail the generation.
+//
+// Add it as a tool to the module and use it with go generate:
+//
+//	go get -tool github.com/kyuff/es-commands/cmd/es-commands-gen
+//
+//	//go:generate go tool es-commands-gen
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	var (
+		out    = flag.String("out", "commands_gen.go", "file to write in the package directory")
+		client = flag.String("client", "Client", "name of the generated client type")
+	)
+	flag.Parse()
+
+	var dir = "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	err := run(dir, *out, *client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "es-commands-gen: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,101 @@
+package account
+
+//go:generate go tool es-commands-gen
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kyuff/es"
+)
+
+type OpenAccount struct {
+	Owner string
+}
+
+func (cmd OpenAccount) CommandName() string {
+	return "OpenAccount"
+}
+
+type CloseAccount struct {
+	Reason string
+}
+
+func (cmd *CloseAccount) CommandName() string {
+	return "CloseAccount"
+}
+
+type AuditAccount struct{}
+
+func (cmd AuditAccount) CommandName() string {
+	return "AuditAccount"
+}
+
+type RenameAccount struct {
+	Owner string
+}
+
+func (cmd RenameAccount) CommandName() string {
+	return "RenameAccount"
+}
+
+type AccountOpened struct {
+	Owner string
+}
+
+func (e AccountOpened) EventName() string {
+	return "AccountOpened"
+}
+
+type Account struct {
+	Open bool
+}
+
+func (a *Account) Handle(ctx context.Context, event es.Event) error {
+	if _, ok := event.Content.(AccountOpened); ok {
+		a.Open = true
+	}
+
+	return nil
+}
+
+type Audit struct{}
+
+func (a *Audit) Handle(ctx context.Context, event es.Event) error {
+	return nil
+}
+
+func OpenAccountExecutor(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error) {
+	return []es.Content{AccountOpened{Owner: cmd.Owner}}, nil
+}
+
+func CloseAccountExecutor(ctx context.Context, cmd *CloseAccount, state *Account) ([]es.Content, error) {
+	if !state.Open {
+		return nil, errors.New("account is not open")
+	}
+
+	return nil, nil
+}
+
+// AuditAccountExecutor is registered on another entity type.
+//
+//escommands:entity audit_log
+func AuditAccountExecutor(ctx context.Context, cmd AuditAccount, state *Audit) ([]es.Content, error) {
+	return nil, nil
+}
+
+// RenameAccountExecutor takes a pointer to a command with a value receiver, like commands.Register allows.
+type RenameAccountExecutor struct{}
+
+func (e RenameAccountExecutor) Execute(ctx context.Context, cmd *RenameAccount, state *Account) ([]es.Content, error) {
+	if !state.Open {
+		return nil, errors.New("account is not open")
+	}
+
+	return nil, nil
+}
+
+// helper has the shape of an executor, but not of a command in the package.
+func helper(ctx context.Context, cmd string, state *Account) ([]es.Content, error) {
+	return nil, nil
+}
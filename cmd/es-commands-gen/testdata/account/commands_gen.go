@@ -0,0 +1,52 @@
+// Code generated by es-commands-gen. DO NOT EDIT.
+
+package account
+
+import (
+	"context"
+
+	commands "github.com/kyuff/es-commands"
+)
+
+// RegisterCommands registers the executors of the package with the Dispatcher.
+func RegisterCommands(dispatcher *commands.Dispatcher, opts ...commands.RegisterOption) error {
+	if err := commands.RegisterFunc(dispatcher, "audit_log", AuditAccountExecutor, opts...); err != nil {
+		return err
+	}
+	if err := commands.RegisterFunc(dispatcher, "account", CloseAccountExecutor, opts...); err != nil {
+		return err
+	}
+	if err := commands.RegisterFunc(dispatcher, "account", OpenAccountExecutor, opts...); err != nil {
+		return err
+	}
+	if err := commands.Register[*RenameAccount, *Account](dispatcher, "account", new(RenameAccountExecutor), opts...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Client dispatches the commands of the package.
+type Client struct {
+	bus commands.CommandBus
+}
+
+func NewClient(bus commands.CommandBus) *Client {
+	return &Client{bus: bus}
+}
+
+func (c *Client) AuditAccount(ctx context.Context, entityID string, cmd AuditAccount) error {
+	return c.bus.Dispatch(ctx, entityID, cmd)
+}
+
+func (c *Client) CloseAccount(ctx context.Context, entityID string, cmd *CloseAccount) error {
+	return c.bus.Dispatch(ctx, entityID, cmd)
+}
+
+func (c *Client) OpenAccount(ctx context.Context, entityID string, cmd OpenAccount) error {
+	return c.bus.Dispatch(ctx, entityID, cmd)
+}
+
+func (c *Client) RenameAccount(ctx context.Context, entityID string, cmd *RenameAccount) error {
+	return c.bus.Dispatch(ctx, entityID, cmd)
+}
@@ -0,0 +1,27 @@
+package duplicate
+
+import (
+	"context"
+
+	"github.com/kyuff/es"
+)
+
+type OpenAccount struct{}
+
+func (cmd OpenAccount) CommandName() string {
+	return "OpenAccount"
+}
+
+type Account struct{}
+
+func (a *Account) Handle(ctx context.Context, event es.Event) error {
+	return nil
+}
+
+func OpenAccountExecutor(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error) {
+	return nil, nil
+}
+
+func OpenAccountAgain(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error) {
+	return nil, nil
+}
@@ -0,0 +1,26 @@
+package proto
+
+import (
+	"context"
+
+	"github.com/kyuff/es"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type Rename struct {
+	wrapperspb.StringValue
+}
+
+func (cmd *Rename) CommandName() string {
+	return "Rename"
+}
+
+type Account struct{}
+
+func (a *Account) Handle(ctx context.Context, event es.Event) error {
+	return nil
+}
+
+func RenameExecutor(ctx context.Context, cmd *Rename, state *Account) ([]es.Content, error) {
+	return nil, nil
+}
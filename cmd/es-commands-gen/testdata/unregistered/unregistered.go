@@ -0,0 +1,7 @@
+package unregistered
+
+type OpenAccount struct{}
+
+func (cmd OpenAccount) CommandName() string {
+	return "OpenAccount"
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidCommand = errors.New("invalid command")
+
+// Codec serializes commands. Unmarshal is given a pointer to the registered command type.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type GobCodec struct{}
+
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// Decode a command registered by name using the Codec it was registered with.
+// Versioned names are decoded with the Codec of the command they are upcast to.
+func (d *Dispatcher) Decode(name string, data []byte) (Command, error) {
+	reg, chain, err := d.registration(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if chain != nil {
+		return decodeVersion(chain, func(v any) error {
+			return reg.codec.Unmarshal(data, v)
+		})
+	}
+
+	cmd, err := reg.decode(func(v any) error {
+		return reg.codec.Unmarshal(data, v)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decode command %s: %w: %w", name, ErrInvalidCommand, err)
+	}
+
+	return cmd, nil
+}
+
+// Encode a command using the Codec it was registered with.
+func (d *Dispatcher) Encode(cmd Command) ([]byte, error) {
+	if cmd == nil {
+		return nil, fmt.Errorf("command %T is nil", cmd)
+	}
+
+	d.mux.RLock()
+	reg, ok := d.executors[cmd.CommandName()]
+	d.mux.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("command %s: %w", cmd.CommandName(), ErrNotRegistered)
+	}
+
+	data, err := reg.codec.Marshal(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("encode command %s: %w", cmd.CommandName(), err)
+	}
+
+	return data, nil
+}
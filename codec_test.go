@@ -0,0 +1,130 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestCodec(t *testing.T) {
+	var (
+		newDispatcher = func(t *testing.T, opts ...commands.RegisterOption) *commands.Dispatcher {
+			var dispatcher = commands.NewDispatcher(&StoreMock{})
+			assert.NoError(t, commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+				return nil, nil
+			}, opts...))
+			assert.NoError(t, commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd *TestPointerCommand, state *StateMock) ([]es.Content, error) {
+				return nil, nil
+			}, opts...))
+			return dispatcher
+		}
+	)
+
+	var codecCases = []struct {
+		name string
+		opts []commands.RegisterOption
+	}{
+		{name: "default json"},
+		{name: "json", opts: []commands.RegisterOption{commands.WithCommandCodec(commands.JSONCodec{})}},
+		{name: "gob", opts: []commands.RegisterOption{commands.WithCommandCodec(commands.GobCodec{})}},
+	}
+
+	for _, tt := range codecCases {
+		t.Run("round trip value command with "+tt.name, func(t *testing.T) {
+			// arrange
+			var dispatcher = newDispatcher(t, tt.opts...)
+
+			// act
+			data, err := dispatcher.Encode(TestCommand{Value: "value"})
+			assert.NoError(t, err)
+			got, err := dispatcher.Decode("TestCommand", data)
+
+			// assert
+			assert.NoError(t, err)
+			assert.Equal[commands.Command](t, TestCommand{Value: "value"}, got)
+		})
+
+		t.Run("round trip pointer command with "+tt.name, func(t *testing.T) {
+			// arrange
+			var dispatcher = newDispatcher(t, tt.opts...)
+
+			// act
+			data, err := dispatcher.Encode(&TestPointerCommand{Value: "pointer"})
+			assert.NoError(t, err)
+			got, err := dispatcher.Decode("TestPointerCommand", data)
+
+			// assert
+			assert.NoError(t, err)
+			cmd, ok := got.(*TestPointerCommand)
+			if assert.Truef(t, ok, "expected *TestPointerCommand, got %T", got) {
+				assert.Equal(t, "pointer", cmd.Value)
+			}
+		})
+	}
+
+	t.Run("use dispatcher codec", func(t *testing.T) {
+		// arrange
+		var dispatcher = commands.New(&StoreMock{}, commands.WithCodec(commands.GobCodec{}))
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		})
+
+		// act
+		data, err := dispatcher.Encode(TestCommand{Value: "value"})
+		assert.NoError(t, err)
+		got, err := dispatcher.Decode("TestCommand", data)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal[commands.Command](t, TestCommand{Value: "value"}, got)
+		assert.Truef(t, data[0] != '{', "expected gob encoding, got %s", data)
+	})
+
+	t.Run("fail decoding unregistered command", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+
+		// act
+		_, err := dispatcher.Decode("Unknown", []byte(`{}`))
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNotRegistered), "expected ErrNotRegistered, got %v", err)
+	})
+
+	t.Run("fail decoding invalid data", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+
+		// act
+		_, err := dispatcher.Decode("TestCommand", []byte(`{`))
+
+		// assert
+		assert.Error(t, err)
+	})
+
+	t.Run("fail encoding unregistered command", func(t *testing.T) {
+		// arrange
+		var dispatcher = commands.NewDispatcher(&StoreMock{})
+
+		// act
+		_, err := dispatcher.Encode(TestCommand{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNotRegistered), "expected ErrNotRegistered, got %v", err)
+	})
+
+	t.Run("fail encoding nil command", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+
+		// act
+		_, err := dispatcher.Encode(nil)
+
+		// assert
+		assert.Error(t, err)
+	})
+}
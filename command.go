@@ -1,5 +1,25 @@
 package commands
 
+import "context"
+
 type Command interface {
 	CommandName() string
 }
+
+// CommandBus dispatches commands to their executors. It is implemented by the Dispatcher
+// and by the transport clients, so code can dispatch locally or to another service.
+type CommandBus interface {
+	Dispatch(ctx context.Context, entityID string, cmd Command) error
+}
+
+// Registry is a CommandBus that describes and decodes the commands it dispatches. It is
+// implemented by the Dispatcher and the Router, so the transports can serve either.
+type Registry interface {
+	CommandBus
+	DispatchResult(ctx context.Context, entityID string, cmd Command) (Result, error)
+	EntityType(name string) (string, bool)
+	Decode(name string, data []byte) (Command, error)
+	DecodeFunc(name string, decode func(v any) error) (Command, error)
+	Commands() []Descriptor
+	Reject(ctx context.Context, entityID, commandName string, payload []byte, err error) error
+}
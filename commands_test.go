@@ -1,5 +1,13 @@
 package commands_test
 
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/kyuff/es"
+)
+
 type TestCommand struct {
 	Value string
 }
@@ -8,6 +16,23 @@ func (cmd TestCommand) CommandName() string {
 	return "TestCommand"
 }
 
+type TestCommandV1 struct {
+	First string
+	Last  string
+}
+
+func (cmd TestCommandV1) CommandName() string {
+	return "TestCommand.v1"
+}
+
+type TestCommandV2 struct {
+	Name string
+}
+
+func (cmd TestCommandV2) CommandName() string {
+	return "TestCommand.v2"
+}
+
 type TestDoubleCommand struct {
 	Value string
 }
@@ -31,3 +56,134 @@ type TestPanicCommand struct {
 func (cmd TestPanicCommand) CommandName() string {
 	panic("TestPanicCommand")
 }
+
+type TestTaggedCommand struct {
+	Owner    string `log:"owner"`
+	Email    string
+	Password string `log:",redact"`
+	Internal string `log:"-"`
+}
+
+func (cmd TestTaggedCommand) CommandName() string {
+	return "TestTaggedCommand"
+}
+
+type TestStringerCommand struct {
+	Owner    string
+	Password string `log:",redact"`
+	Next     *TestStringerCommand
+}
+
+func (cmd TestStringerCommand) CommandName() string {
+	return "TestStringerCommand"
+}
+
+func (cmd TestStringerCommand) String() string {
+	return cmd.Owner + ":" + cmd.Password
+}
+
+type TestCollectionCommand struct {
+	Users []TestTaggedCommand
+	ByKey map[string]TestTaggedCommand
+	Tags  []string
+}
+
+func (cmd TestCollectionCommand) CommandName() string {
+	return "TestCollectionCommand"
+}
+
+type TestLogValuerCommand struct {
+	Value string
+}
+
+func (cmd TestLogValuerCommand) CommandName() string {
+	return "TestLogValuerCommand"
+}
+
+func (cmd TestLogValuerCommand) LogValue() slog.Value {
+	return slog.StringValue("logged-" + cmd.Value)
+}
+
+type TestCompensateCommand struct {
+	Value string
+}
+
+func (cmd TestCompensateCommand) CommandName() string {
+	return "TestCompensateCommand"
+}
+
+type TestEvent struct {
+	Value string
+}
+
+func (e TestEvent) EventName() string {
+	return "TestEvent"
+}
+
+type TestProcessState struct {
+	Values []string
+}
+
+func (s *TestProcessState) Handle(ctx context.Context, event es.Event) error {
+	if e, ok := event.Content.(TestEvent); ok {
+		s.Values = append(s.Values, e.Value)
+	}
+
+	return nil
+}
+
+type TestAggregate struct {
+	Values []string
+}
+
+func (a *TestAggregate) Handle(ctx context.Context, event es.Event) error {
+	if e, ok := event.Content.(TestEvent); ok {
+		a.Values = append(a.Values, e.Value)
+	}
+
+	return nil
+}
+
+func (a *TestAggregate) HandleTestCommand(ctx context.Context, cmd TestCommand) ([]es.Content, error) {
+	if cmd.Value == "fail" {
+		return nil, errors.New("executor-error")
+	}
+
+	return []es.Content{TestEvent{Value: cmd.Value + ":" + lastValue(a.Values)}}, nil
+}
+
+func (a *TestAggregate) HandleTestPointerCommand(ctx context.Context, cmd *TestPointerCommand) ([]es.Content, error) {
+	return []es.Content{TestEvent{Value: cmd.Value}}, nil
+}
+
+func lastValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[len(values)-1]
+}
+
+type TestInvalidAggregate struct{}
+
+func (a *TestInvalidAggregate) Handle(ctx context.Context, event es.Event) error {
+	return nil
+}
+
+func (a *TestInvalidAggregate) HandleTestCommand(cmd TestCommand) error {
+	return nil
+}
+
+type TestAmbiguousAggregate struct{}
+
+func (a *TestAmbiguousAggregate) Handle(ctx context.Context, event es.Event) error {
+	return nil
+}
+
+func (a *TestAmbiguousAggregate) HandleTestCommand(ctx context.Context, cmd TestCommand) ([]es.Content, error) {
+	return nil, nil
+}
+
+func (a *TestAmbiguousAggregate) HandleTestDoubleCommand(ctx context.Context, cmd TestDoubleCommand) ([]es.Content, error) {
+	return nil, nil
+}
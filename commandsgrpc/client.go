@@ -0,0 +1,70 @@
+package commandsgrpc
+
+import (
+	"context"
+	"fmt"
+
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/commandsgrpc/commandspb"
+	"google.golang.org/grpc"
+)
+
+var _ commands.CommandBus = (*Client)(nil)
+
+type ClientOption func(c *Client)
+
+// WithCodec sets the Codec used to encode commands. It must match the Codec
+// the commands are registered with on the server. Defaults to commands.JSONCodec.
+func WithCodec(codec commands.Codec) ClientOption {
+	return func(c *Client) {
+		c.codec = codec
+	}
+}
+
+// NewClient dispatches commands to a remote CommandService.
+func NewClient(conn grpc.ClientConnInterface, opts ...ClientOption) *Client {
+	var c = &Client{
+		client: commandspb.NewCommandServiceClient(conn),
+		codec:  commands.JSONCodec{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type Client struct {
+	client commandspb.CommandServiceClient
+	codec  commands.Codec
+}
+
+func (c *Client) Dispatch(ctx context.Context, entityID string, cmd commands.Command) error {
+	_, err := c.DispatchResult(ctx, entityID, cmd)
+	return err
+}
+
+// DispatchResult dispatches the command and returns the position and names of the written events.
+func (c *Client) DispatchResult(ctx context.Context, entityID string, cmd commands.Command) (*commandspb.CommandResponse, error) {
+	if cmd == nil {
+		return nil, fmt.Errorf("command %T is nil", cmd)
+	}
+
+	payload, err := c.codec.Marshal(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("encode command %s: %w", cmd.CommandName(), err)
+	}
+
+	response, err := c.client.Dispatch(ctx, &commandspb.CommandRequest{
+		CommandName: cmd.CommandName(),
+		EntityId:    entityID,
+		Payload:     payload,
+		Metadata:    commands.MetadataFromContext(ctx),
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	return response, nil
+}
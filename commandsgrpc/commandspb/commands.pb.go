@@ -0,0 +1,229 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: commandspb/commands.proto
+
+package commandspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CommandRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the command as returned by CommandName.
+	CommandName string `protobuf:"bytes,1,opt,name=command_name,json=commandName,proto3" json:"command_name,omitempty"`
+	// Type of the entity. When empty, the entity type the command is registered with is used.
+	EntityType string `protobuf:"bytes,2,opt,name=entity_type,json=entityType,proto3" json:"entity_type,omitempty"`
+	EntityId   string `protobuf:"bytes,3,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	// Payload is the command encoded with the Codec it is registered with.
+	Payload       []byte            `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	Metadata      map[string]string `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandRequest) Reset() {
+	*x = CommandRequest{}
+	mi := &file_commandspb_commands_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandRequest) ProtoMessage() {}
+
+func (x *CommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_commandspb_commands_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandRequest.ProtoReflect.Descriptor instead.
+func (*CommandRequest) Descriptor() ([]byte, []int) {
+	return file_commandspb_commands_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CommandRequest) GetCommandName() string {
+	if x != nil {
+		return x.CommandName
+	}
+	return ""
+}
+
+func (x *CommandRequest) GetEntityType() string {
+	if x != nil {
+		return x.EntityType
+	}
+	return ""
+}
+
+func (x *CommandRequest) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *CommandRequest) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *CommandRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type CommandResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Position of the stream after the command was executed.
+	Position int64 `protobuf:"varint,1,opt,name=position,proto3" json:"position,omitempty"`
+	// Names of the events written by the command.
+	Events        []string `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandResponse) Reset() {
+	*x = CommandResponse{}
+	mi := &file_commandspb_commands_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandResponse) ProtoMessage() {}
+
+func (x *CommandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_commandspb_commands_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandResponse.ProtoReflect.Descriptor instead.
+func (*CommandResponse) Descriptor() ([]byte, []int) {
+	return file_commandspb_commands_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CommandResponse) GetPosition() int64 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *CommandResponse) GetEvents() []string {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+var File_commandspb_commands_proto protoreflect.FileDescriptor
+
+const file_commandspb_commands_proto_rawDesc = "" +
+	"\n" +
+	"\x19commandspb/commands.proto\x12\rescommands.v1\"\x91\x02\n" +
+	"\x0eCommandRequest\x12!\n" +
+	"\fcommand_name\x18\x01 \x01(\tR\vcommandName\x12\x1f\n" +
+	"\ventity_type\x18\x02 \x01(\tR\n" +
+	"entityType\x12\x1b\n" +
+	"\tentity_id\x18\x03 \x01(\tR\bentityId\x12\x18\n" +
+	"\apayload\x18\x04 \x01(\fR\apayload\x12G\n" +
+	"\bmetadata\x18\x05 \x03(\v2+.escommands.v1.CommandRequest.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"E\n" +
+	"\x0fCommandResponse\x12\x1a\n" +
+	"\bposition\x18\x01 \x01(\x03R\bposition\x12\x16\n" +
+	"\x06events\x18\x02 \x03(\tR\x06events2[\n" +
+	"\x0eCommandService\x12I\n" +
+	"\bDispatch\x12\x1d.escommands.v1.CommandRequest\x1a\x1e.escommands.v1.CommandResponseB6Z4github.com/kyuff/es-commands/commandsgrpc/commandspbb\x06proto3"
+
+var (
+	file_commandspb_commands_proto_rawDescOnce sync.Once
+	file_commandspb_commands_proto_rawDescData []byte
+)
+
+func file_commandspb_commands_proto_rawDescGZIP() []byte {
+	file_commandspb_commands_proto_rawDescOnce.Do(func() {
+		file_commandspb_commands_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_commandspb_commands_proto_rawDesc), len(file_commandspb_commands_proto_rawDesc)))
+	})
+	return file_commandspb_commands_proto_rawDescData
+}
+
+var file_commandspb_commands_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_commandspb_commands_proto_goTypes = []any{
+	(*CommandRequest)(nil),  // 0: escommands.v1.CommandRequest
+	(*CommandResponse)(nil), // 1: escommands.v1.CommandResponse
+	nil,                     // 2: escommands.v1.CommandRequest.MetadataEntry
+}
+var file_commandspb_commands_proto_depIdxs = []int32{
+	2, // 0: escommands.v1.CommandRequest.metadata:type_name -> escommands.v1.CommandRequest.MetadataEntry
+	0, // 1: escommands.v1.CommandService.Dispatch:input_type -> escommands.v1.CommandRequest
+	1, // 2: escommands.v1.CommandService.Dispatch:output_type -> escommands.v1.CommandResponse
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_commandspb_commands_proto_init() }
+func file_commandspb_commands_proto_init() {
+	if File_commandspb_commands_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_commandspb_commands_proto_rawDesc), len(file_commandspb_commands_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_commandspb_commands_proto_goTypes,
+		DependencyIndexes: file_commandspb_commands_proto_depIdxs,
+		MessageInfos:      file_commandspb_commands_proto_msgTypes,
+	}.Build()
+	File_commandspb_commands_proto = out.File
+	file_commandspb_commands_proto_goTypes = nil
+	file_commandspb_commands_proto_depIdxs = nil
+}
@@ -0,0 +1,50 @@
+package commandsgrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	commands "github.com/kyuff/es-commands"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errorCodes = []struct {
+	target error
+	code   codes.Code
+}{
+	{target: commands.ErrInvalidCommand, code: codes.InvalidArgument},
+	{target: commands.ErrNotRegistered, code: codes.NotFound},
+	{target: commands.ErrForbidden, code: codes.PermissionDenied},
+	{target: commands.ErrRateLimited, code: codes.ResourceExhausted},
+	{target: commands.ErrCircuitOpen, code: codes.Unavailable},
+	{target: commands.ErrCommandTimeout, code: codes.DeadlineExceeded},
+	{target: context.DeadlineExceeded, code: codes.DeadlineExceeded},
+	{target: context.Canceled, code: codes.Canceled},
+}
+
+func toStatus(err error) error {
+	for _, e := range errorCodes {
+		if errors.Is(err, e.target) {
+			return status.Error(e.code, err.Error())
+		}
+	}
+
+	return status.Error(codes.Unknown, err.Error())
+}
+
+func fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	for _, e := range errorCodes {
+		if st.Code() == e.code {
+			return fmt.Errorf("%s: %w", st.Message(), e.target)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,4 @@
+package commandsgrpc
+
+// The protoc plugins are tools of the module, so only protoc itself must be installed.
+//go:generate sh -c "protoc --plugin=protoc-gen-go=$(go tool -n protoc-gen-go) --plugin=protoc-gen-go-grpc=$(go tool -n protoc-gen-go-grpc) --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative commandspb/commands.proto"
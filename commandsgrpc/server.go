@@ -0,0 +1,55 @@
+package commandsgrpc
+
+import (
+	"context"
+	"fmt"
+
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/commandsgrpc/commandspb"
+)
+
+// NewServer adapts a Dispatcher, or a Router, to the CommandService. Register it with
+//
+//	commandspb.RegisterCommandServiceServer(grpcServer, commandsgrpc.NewServer(dispatcher))
+func NewServer(dispatcher commands.Registry) *Server {
+	return &Server{
+		dispatcher: dispatcher,
+	}
+}
+
+type Server struct {
+	commandspb.UnimplementedCommandServiceServer
+	dispatcher commands.Registry
+}
+
+func (s *Server) Dispatch(ctx context.Context, req *commandspb.CommandRequest) (*commandspb.CommandResponse, error) {
+	if len(req.GetMetadata()) > 0 {
+		ctx = commands.ContextWithMetadata(ctx, req.GetMetadata())
+	}
+
+	registered, ok := s.dispatcher.EntityType(req.GetCommandName())
+	if !ok || (req.GetEntityType() != "" && req.GetEntityType() != registered) {
+		err := fmt.Errorf("command %s for entity type %q: %w", req.GetCommandName(), req.GetEntityType(), commands.ErrNotRegistered)
+		return nil, toStatus(s.dispatcher.Reject(ctx, req.GetEntityId(), req.GetCommandName(), req.GetPayload(), err))
+	}
+
+	cmd, err := s.dispatcher.Decode(req.GetCommandName(), req.GetPayload())
+	if err != nil {
+		return nil, toStatus(s.dispatcher.Reject(ctx, req.GetEntityId(), req.GetCommandName(), req.GetPayload(), err))
+	}
+
+	result, err := s.dispatcher.DispatchResult(ctx, req.GetEntityId(), cmd)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	var response = &commandspb.CommandResponse{
+		Position: result.Position,
+		Events:   make([]string, 0, len(result.Events)),
+	}
+	for _, event := range result.Events {
+		response.Events = append(response.Events, event.EventName())
+	}
+
+	return response, nil
+}
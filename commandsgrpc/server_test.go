@@ -0,0 +1,320 @@
+package commandsgrpc_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/commandsgrpc"
+	"github.com/kyuff/es-commands/commandsgrpc/commandspb"
+	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es/storage/inmemory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type OpenAccount struct {
+	Owner string `json:"owner"`
+}
+
+func (cmd OpenAccount) CommandName() string {
+	return "OpenAccount"
+}
+
+type CloseAccount struct {
+	Reason string `json:"reason"`
+}
+
+func (cmd *CloseAccount) CommandName() string {
+	return "CloseAccount"
+}
+
+type AccountOpened struct {
+	Owner string
+}
+
+func (e AccountOpened) EventName() string {
+	return "AccountOpened"
+}
+
+type AccountClosed struct {
+	Reason string
+}
+
+func (e AccountClosed) EventName() string {
+	return "AccountClosed"
+}
+
+type Account struct {
+	Opened bool
+}
+
+func (a *Account) Handle(ctx context.Context, event es.Event) error {
+	if _, ok := event.Content.(AccountOpened); ok {
+		a.Opened = true
+	}
+
+	return nil
+}
+
+func newDispatcher(t *testing.T, opts ...commands.Option) *commands.Dispatcher {
+	t.Helper()
+	var storage = inmemory.New()
+	assert.NoError(t, storage.Register("account", AccountOpened{}, AccountClosed{}))
+
+	var dispatcher = commands.New(es.NewStore(storage), opts...)
+	assert.NoError(t, commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error) {
+		return []es.Content{AccountOpened{Owner: cmd.Owner}}, nil
+	}))
+	assert.NoError(t, commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd *CloseAccount, state *Account) ([]es.Content, error) {
+		if !state.Opened {
+			return nil, errors.New("account not open")
+		}
+		return []es.Content{AccountClosed{Reason: cmd.Reason}}, nil
+	}))
+
+	return dispatcher
+}
+
+func newConn(t *testing.T, dispatcher commands.Registry) *grpc.ClientConn {
+	t.Helper()
+	var (
+		listener = bufconn.Listen(1 << 20)
+		server   = grpc.NewServer()
+	)
+
+	commandspb.RegisterCommandServiceServer(server, commandsgrpc.NewServer(dispatcher))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return conn
+}
+
+func TestServer(t *testing.T) {
+	t.Run("dispatch command with router", func(t *testing.T) {
+		// arrange
+		router, err := commands.NewRouter(commands.RouteEntityType("account", newDispatcher(t)))
+		assert.NoError(t, err)
+		var client = commandspb.NewCommandServiceClient(newConn(t, router))
+
+		// act
+		got, err := client.Dispatch(t.Context(), &commandspb.CommandRequest{
+			CommandName: "OpenAccount",
+			EntityType:  "account",
+			EntityId:    "account-1",
+			Payload:     []byte(`{"owner": "owner-1"}`),
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), got.GetPosition())
+	})
+
+	t.Run("dispatch command", func(t *testing.T) {
+		// arrange
+		var client = commandspb.NewCommandServiceClient(newConn(t, newDispatcher(t)))
+
+		// act
+		got, err := client.Dispatch(t.Context(), &commandspb.CommandRequest{
+			CommandName: "OpenAccount",
+			EntityType:  "account",
+			EntityId:    "account-1",
+			Payload:     []byte(`{"owner": "owner-1"}`),
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), got.GetPosition())
+		assert.EqualSlice(t, []string{"AccountOpened"}, got.GetEvents())
+	})
+
+	t.Run("pass metadata in context", func(t *testing.T) {
+		// arrange
+		var (
+			got        commands.Metadata
+			dispatcher = newDispatcher(t, commands.WithMiddlewares(commands.MiddlewareFunc(func(next func(ctx context.Context, command commands.Command) error) func(ctx context.Context, command commands.Command) error {
+				return func(ctx context.Context, command commands.Command) error {
+					got = commands.MetadataFromContext(ctx)
+					return next(ctx, command)
+				}
+			})))
+			client = commandspb.NewCommandServiceClient(newConn(t, dispatcher))
+		)
+
+		// act
+		_, err := client.Dispatch(t.Context(), &commandspb.CommandRequest{
+			CommandName: "OpenAccount",
+			EntityId:    "account-1",
+			Payload:     []byte(`{}`),
+			Metadata:    map[string]string{"tenant": "tenant-1"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "tenant-1", got["tenant"])
+	})
+
+	t.Run("journal rejected requests", func(t *testing.T) {
+		// arrange
+		var (
+			entries    []commands.JournalEntry
+			dispatcher = newDispatcher(t, commands.WithJournal(commands.JournalFunc(func(ctx context.Context, entry commands.JournalEntry) error {
+				entries = append(entries, entry)
+				return nil
+			})))
+			client = commandspb.NewCommandServiceClient(newConn(t, dispatcher))
+		)
+
+		// act
+		_, err := client.Dispatch(t.Context(), &commandspb.CommandRequest{
+			CommandName: "OpenAccount",
+			EntityId:    "account-1",
+			Payload:     []byte(`{`),
+			Metadata:    map[string]string{"tenant": "tenant-1"},
+		})
+
+		// assert
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		if assert.Equal(t, 1, len(entries)) {
+			assert.Equal(t, "OpenAccount", entries[0].CommandName)
+			assert.Equal(t, `{`, string(entries[0].Payload))
+			assert.Equal(t, "tenant-1", entries[0].Metadata["tenant"])
+			assert.Equal(t, commands.OutcomeRejected, entries[0].Outcome)
+		}
+	})
+
+	var errorCases = []struct {
+		name string
+		req  *commandspb.CommandRequest
+		code codes.Code
+	}{
+		{
+			name: "fail with unknown command",
+			req:  &commandspb.CommandRequest{CommandName: "Unknown", EntityId: "account-1"},
+			code: codes.NotFound,
+		},
+		{
+			name: "fail with wrong entity type",
+			req:  &commandspb.CommandRequest{CommandName: "OpenAccount", EntityType: "customer", EntityId: "account-1", Payload: []byte(`{}`)},
+			code: codes.NotFound,
+		},
+		{
+			name: "fail with invalid payload",
+			req:  &commandspb.CommandRequest{CommandName: "OpenAccount", EntityId: "account-1", Payload: []byte(`{`)},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "fail with executor error",
+			req:  &commandspb.CommandRequest{CommandName: "CloseAccount", EntityId: "account-1", Payload: []byte(`{}`)},
+			code: codes.Unknown,
+		},
+	}
+
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			var client = commandspb.NewCommandServiceClient(newConn(t, newDispatcher(t)))
+
+			// act
+			_, err := client.Dispatch(t.Context(), tt.req)
+
+			// assert
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
+
+func TestClient(t *testing.T) {
+	t.Run("dispatch command", func(t *testing.T) {
+		// arrange
+		var sut = commandsgrpc.NewClient(newConn(t, newDispatcher(t)))
+
+		// act
+		err := sut.Dispatch(t.Context(), "account-1", OpenAccount{Owner: "owner-1"})
+
+		// assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("dispatch pointer command with result", func(t *testing.T) {
+		// arrange
+		var sut = commandsgrpc.NewClient(newConn(t, newDispatcher(t)))
+		assert.NoError(t, sut.Dispatch(t.Context(), "account-1", OpenAccount{Owner: "owner-1"}))
+
+		// act
+		got, err := sut.DispatchResult(t.Context(), "account-1", &CloseAccount{Reason: "done"})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), got.GetPosition())
+		assert.EqualSlice(t, []string{"AccountClosed"}, got.GetEvents())
+	})
+
+	t.Run("send metadata from context", func(t *testing.T) {
+		// arrange
+		var (
+			got        commands.Metadata
+			dispatcher = newDispatcher(t, commands.WithMiddlewares(commands.MiddlewareFunc(func(next func(ctx context.Context, command commands.Command) error) func(ctx context.Context, command commands.Command) error {
+				return func(ctx context.Context, command commands.Command) error {
+					got = commands.MetadataFromContext(ctx)
+					return next(ctx, command)
+				}
+			})))
+			sut = commandsgrpc.NewClient(newConn(t, dispatcher))
+			ctx = commands.ContextWithMetadata(t.Context(), commands.Metadata{"tenant": "tenant-1"})
+		)
+
+		// act
+		err := sut.Dispatch(ctx, "account-1", OpenAccount{})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "tenant-1", got["tenant"])
+	})
+
+	t.Run("return typed errors", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = newDispatcher(t, commands.WithMiddlewares(commands.Authorize(commands.PolicyFunc(func(ctx context.Context, actor commands.Actor, commandName, entityType, entityID string) error {
+				return nil
+			}))))
+			sut = commandsgrpc.NewClient(newConn(t, dispatcher))
+		)
+
+		// act
+		err := sut.Dispatch(t.Context(), "account-1", OpenAccount{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrForbidden), "expected ErrForbidden, got %v", err)
+	})
+
+	t.Run("fail with nil command", func(t *testing.T) {
+		// arrange
+		var sut = commandsgrpc.NewClient(newConn(t, newDispatcher(t)))
+
+		// act
+		err := sut.Dispatch(t.Context(), "account-1", nil)
+
+		// assert
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,120 @@
+package commandshttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	commands "github.com/kyuff/es-commands"
+)
+
+var _ commands.CommandBus = (*Client)(nil)
+
+type ClientOption func(c *Client)
+
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// WithCodec sets the Codec used to encode commands. Commands encoded with other codecs
+// than commands.JSONCodec are decoded by the codec they are registered with on the server.
+func WithCodec(codec commands.Codec) ClientOption {
+	return func(c *Client) {
+		c.codec = codec
+	}
+}
+
+// WithErrorCode makes errors responded with code match target,
+// mirroring WithErrorStatus on the Handler.
+func WithErrorCode(code string, target error) ClientOption {
+	return func(c *Client) {
+		c.mappings = append(c.mappings, errorMapping{
+			target: target,
+			code:   code,
+		})
+	}
+}
+
+// NewClient dispatches commands to a Handler served at baseURL.
+func NewClient(baseURL string, opts ...ClientOption) *Client {
+	var c = &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  http.DefaultClient,
+		codec:   commands.JSONCodec{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type Client struct {
+	baseURL  string
+	client   *http.Client
+	codec    commands.Codec
+	mappings []errorMapping
+}
+
+func (c *Client) Dispatch(ctx context.Context, entityID string, cmd commands.Command) error {
+	_, err := c.DispatchResult(ctx, entityID, cmd)
+	return err
+}
+
+// DispatchResult dispatches the command and returns the position and names of the written events.
+func (c *Client) DispatchResult(ctx context.Context, entityID string, cmd commands.Command) (Response, error) {
+	if cmd == nil {
+		return Response{}, fmt.Errorf("command %T is nil", cmd)
+	}
+
+	body, err := c.codec.Marshal(cmd)
+	if err != nil {
+		return Response{}, fmt.Errorf("encode command %s: %w", cmd.CommandName(), err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.baseURL+"/"+url.PathEscape(entityID)+"/"+url.PathEscape(cmd.CommandName()),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return Response{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if _, ok := c.codec.(commands.JSONCodec); ok {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return Response{}, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode != http.StatusOK {
+		var errResponse ErrorResponse
+		err = json.NewDecoder(res.Body).Decode(&errResponse)
+		if err != nil {
+			return Response{}, fmt.Errorf("dispatch %s: unexpected status %d", cmd.CommandName(), res.StatusCode)
+		}
+
+		return Response{}, newError(c.mappings, res.StatusCode, errResponse.Error, res.Header)
+	}
+
+	var response Response
+	err = json.NewDecoder(res.Body).Decode(&response)
+	if err != nil {
+		return Response{}, fmt.Errorf("decode response: %w", err)
+	}
+
+	return response, nil
+}
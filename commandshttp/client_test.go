@@ -0,0 +1,191 @@
+package commandshttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/commandshttp"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+type Rename struct {
+	Name string
+}
+
+func (cmd Rename) CommandName() string {
+	return "Rename"
+}
+
+func newServer(t *testing.T, dispatcher *commands.Dispatcher, opts ...commandshttp.Option) string {
+	t.Helper()
+	var server = httptest.NewServer(commandshttp.NewHandler(dispatcher, opts...))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestClient(t *testing.T) {
+	t.Run("dispatch command", func(t *testing.T) {
+		// arrange
+		var sut = commandshttp.NewClient(newServer(t, newDispatcher(t)))
+
+		// act
+		err := sut.Dispatch(t.Context(), "account-1", OpenAccount{Owner: "owner-1"})
+
+		// assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("dispatch pointer command with result", func(t *testing.T) {
+		// arrange
+		var sut = commandshttp.NewClient(newServer(t, newDispatcher(t)))
+		assert.NoError(t, sut.Dispatch(t.Context(), "account-1", OpenAccount{Owner: "owner-1"}))
+
+		// act
+		got, err := sut.DispatchResult(t.Context(), "account-1", &CloseAccount{Reason: "done"})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), got.Position)
+		assert.EqualSlice(t, []string{"AccountClosed"}, got.Events)
+	})
+
+	t.Run("dispatch command with registered codec", func(t *testing.T) {
+		// arrange
+		var (
+			got        Rename
+			dispatcher = newDispatcher(t)
+			sut        = commandshttp.NewClient(newServer(t, dispatcher), commandshttp.WithCodec(commands.GobCodec{}))
+		)
+
+		assert.NoError(t, commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd Rename, state *Account) ([]es.Content, error) {
+			got = cmd
+			return nil, nil
+		}, commands.WithCommandCodec(commands.GobCodec{})))
+
+		// act
+		err := sut.Dispatch(t.Context(), "account-1", Rename{Name: "new name"})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "new name", got.Name)
+	})
+
+	t.Run("return typed errors", func(t *testing.T) {
+		var testCases = []struct {
+			name    string
+			opts    []commands.Option
+			command commands.Command
+			target  error
+		}{
+			{
+				name:    "not registered",
+				command: Rename{},
+				target:  commands.ErrNotRegistered,
+			},
+			{
+				name: "forbidden",
+				opts: []commands.Option{commands.WithMiddlewares(commands.Authorize(commands.PolicyFunc(func(ctx context.Context, actor commands.Actor, commandName, entityType, entityID string) error {
+					return nil
+				})))},
+				command: OpenAccount{},
+				target:  commands.ErrForbidden,
+			},
+			{
+				name:    "timeout",
+				opts:    []commands.Option{commands.WithMiddlewares(blocking()), commands.WithDefaultTimeout(time.Millisecond)},
+				command: OpenAccount{},
+				target:  commands.ErrCommandTimeout,
+			},
+		}
+
+		for _, tt := range testCases {
+			t.Run(tt.name, func(t *testing.T) {
+				// arrange
+				var sut = commandshttp.NewClient(newServer(t, newDispatcher(t, tt.opts...)))
+
+				// act
+				err := sut.Dispatch(t.Context(), "account-1", tt.command)
+
+				// assert
+				assert.Truef(t, errors.Is(err, tt.target), "expected %v, got %v", tt.target, err)
+				var httpErr *commandshttp.Error
+				assert.Truef(t, errors.As(err, &httpErr), "expected *commandshttp.Error, got %T", err)
+			})
+		}
+	})
+
+	t.Run("return rate limit with retry after", func(t *testing.T) {
+		// arrange
+		var sut = commandshttp.NewClient(newServer(t, newDispatcher(t, commands.WithMiddlewares(commands.RateLimit(0.5, 1)))))
+		assert.NoError(t, sut.Dispatch(t.Context(), "account-1", OpenAccount{}))
+
+		// act
+		err := sut.Dispatch(t.Context(), "account-2", OpenAccount{})
+
+		// assert
+		var limitErr *commands.RateLimitError
+		if assert.Truef(t, errors.As(err, &limitErr), "expected *commands.RateLimitError, got %v", err) {
+			assert.Equal(t, 2*time.Second, limitErr.RetryAfter)
+		}
+		assert.Truef(t, errors.Is(err, commands.ErrRateLimited), "expected ErrRateLimited")
+	})
+
+	t.Run("return mapped domain errors", func(t *testing.T) {
+		// arrange
+		var sut = commandshttp.NewClient(
+			newServer(t, newDispatcher(t), commandshttp.WithErrorStatus(errAccountClosed, http.StatusConflict, "account_closed")),
+			commandshttp.WithErrorCode("account_closed", errAccountClosed),
+		)
+
+		// act
+		err := sut.Dispatch(t.Context(), "account-1", &CloseAccount{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, errAccountClosed), "expected errAccountClosed, got %v", err)
+	})
+
+	t.Run("fail with nil command", func(t *testing.T) {
+		// arrange
+		var sut = commandshttp.NewClient(newServer(t, newDispatcher(t)))
+
+		// act
+		err := sut.Dispatch(t.Context(), "account-1", nil)
+
+		// assert
+		assert.Error(t, err)
+	})
+
+	t.Run("dispatch locally or remote", func(t *testing.T) {
+		// arrange
+		var (
+			local = newDispatcher(t)
+			buses = map[string]commands.CommandBus{
+				"local":  local,
+				"remote": commandshttp.NewClient(newServer(t, local)),
+			}
+		)
+
+		for name, bus := range buses {
+			// act
+			err := bus.Dispatch(t.Context(), name, OpenAccount{Owner: name})
+
+			// assert
+			assert.NoError(t, err)
+		}
+	})
+}
+
+func blocking() commands.Middleware {
+	return commands.MiddlewareFunc(func(next func(ctx context.Context, command commands.Command) error) func(ctx context.Context, command commands.Command) error {
+		return func(ctx context.Context, command commands.Command) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package commandshttp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	commands "github.com/kyuff/es-commands"
+)
+
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error is returned by the Client when the Handler responds with an error.
+// It unwraps to the error the code is mapped to, so errors.Is works across the wire.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// codeInternal is the code of errors that are not mapped to a status.
+const codeInternal = "internal"
+
+type errorMapping struct {
+	target error
+	status int
+	code   string
+}
+
+var errorMappings = []errorMapping{
+	{target: commands.ErrInvalidCommand, status: http.StatusBadRequest, code: "bad_request"},
+	{target: commands.ErrNotRegistered, status: http.StatusNotFound, code: "not_registered"},
+	{target: commands.ErrForbidden, status: http.StatusForbidden, code: "forbidden"},
+	{target: commands.ErrRateLimited, status: http.StatusTooManyRequests, code: "rate_limited"},
+	{target: commands.ErrCircuitOpen, status: http.StatusServiceUnavailable, code: "circuit_open"},
+	{target: commands.ErrCommandTimeout, status: http.StatusGatewayTimeout, code: "timeout"},
+	{target: context.Canceled, status: 499, code: "canceled"},
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := errorStatus(h.mappings, err)
+
+	var message = err.Error()
+	if code == codeInternal {
+		h.onError(r.Context(), err)
+		message = http.StatusText(status)
+	}
+
+	if retryAfter, ok := retryAfter(err); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+
+	writeJSON(w, status, ErrorResponse{
+		Error: ErrorBody{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
+
+func errorStatus(mappings []errorMapping, err error) (int, string) {
+	for _, m := range slices.Concat(mappings, errorMappings) {
+		if errors.Is(err, m.target) {
+			return m.status, m.code
+		}
+	}
+
+	return http.StatusInternalServerError, codeInternal
+}
+
+func retryAfter(err error) (time.Duration, bool) {
+	var (
+		limitErr *commands.RateLimitError
+		openErr  *commands.CircuitOpenError
+	)
+	switch {
+	case errors.As(err, &limitErr) && limitErr.RetryAfter > 0:
+		return limitErr.RetryAfter, true
+	case errors.As(err, &openErr) && openErr.RetryAfter > 0:
+		return openErr.RetryAfter, true
+	default:
+		return 0, false
+	}
+}
+
+func newError(mappings []errorMapping, status int, body ErrorBody, header http.Header) *Error {
+	var e = &Error{
+		Status:  status,
+		Code:    body.Code,
+		Message: body.Message,
+	}
+
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	for _, m := range slices.Concat(mappings, errorMappings) {
+		if m.code != body.Code {
+			continue
+		}
+
+		switch m.target {
+		case commands.ErrRateLimited:
+			e.err = &commands.RateLimitError{RetryAfter: retryAfter}
+		case commands.ErrCircuitOpen:
+			e.err = &commands.CircuitOpenError{RetryAfter: retryAfter}
+		default:
+			e.err = m.target
+		}
+		break
+	}
+
+	return e
+}
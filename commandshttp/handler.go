@@ -0,0 +1,168 @@
+package commandshttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	commands "github.com/kyuff/es-commands"
+)
+
+type Option func(h *Handler)
+
+// WithErrorStatus responds with status and code when a dispatch fails with an error matching target.
+// It takes precedence over the built-in mapping, and is meant for domain errors returned by executors.
+func WithErrorStatus(target error, status int, code string) Option {
+	return func(h *Handler) {
+		h.mappings = append(h.mappings, errorMapping{
+			target: target,
+			status: status,
+			code:   code,
+		})
+	}
+}
+
+// WithMaxBodySize limits the size of a command in the request body. Defaults to 1MB.
+func WithMaxBodySize(size int64) Option {
+	return func(h *Handler) {
+		h.maxBodySize = size
+	}
+}
+
+// WithOnError sets the func called with errors that are not mapped to a status. The client
+// only gets a generic message for them, as they may reveal the internals of the service.
+func WithOnError(fn func(ctx context.Context, err error)) Option {
+	return func(h *Handler) {
+		h.onError = fn
+	}
+}
+
+// NewHandler exposes the commands registered in the Dispatcher, or routed by a Router, as
+//
+//	POST /{entityType}/{entityID}/{commandName}
+//	POST /{entityID}/{commandName}
+//
+// with the command as JSON in the request body. Without the entity type in the path,
+// the entity type the command is registered with is used.
+// Request bodies with a Content-Type other than application/json are decoded
+// with the Codec the command is registered with.
+func NewHandler(dispatcher commands.Registry, opts ...Option) *Handler {
+	var h = &Handler{
+		dispatcher:  dispatcher,
+		mux:         http.NewServeMux(),
+		maxBodySize: 1 << 20,
+		onError:     func(ctx context.Context, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.mux.HandleFunc("POST /{entityType}/{entityID}/{commandName}", h.dispatch)
+	h.mux.HandleFunc("POST /{entityID}/{commandName}", h.dispatch)
+
+	return h
+}
+
+type Handler struct {
+	dispatcher  commands.Registry
+	mux         *http.ServeMux
+	mappings    []errorMapping
+	maxBodySize int64
+	onError     func(ctx context.Context, err error)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+type Response struct {
+	Position int64    `json:"position"`
+	Events   []string `json:"events"`
+}
+
+func (h *Handler) dispatch(w http.ResponseWriter, r *http.Request) {
+	var (
+		entityType = r.PathValue("entityType")
+		entityID   = r.PathValue("entityID")
+		name       = r.PathValue("commandName")
+	)
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodySize))
+	if err != nil {
+		h.writeError(w, r, h.dispatcher.Reject(r.Context(), entityID, name, payload, fmt.Errorf("%w: %w", commands.ErrInvalidCommand, err)))
+		return
+	}
+
+	registered, ok := h.dispatcher.EntityType(name)
+	if !ok || (entityType != "" && registered != entityType) {
+		err = fmt.Errorf("command %s for entity type %q: %w", name, entityType, commands.ErrNotRegistered)
+		h.writeError(w, r, h.dispatcher.Reject(r.Context(), entityID, name, payload, err))
+		return
+	}
+
+	cmd, err := h.decode(r, name, payload)
+	if err != nil {
+		h.writeError(w, r, h.dispatcher.Reject(r.Context(), entityID, name, payload, fmt.Errorf("%w: %w", commands.ErrInvalidCommand, err)))
+		return
+	}
+
+	result, err := h.dispatcher.DispatchResult(r.Context(), entityID, cmd)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	var response = Response{
+		Position: result.Position,
+		Events:   make([]string, 0, len(result.Events)),
+	}
+	for _, event := range result.Events {
+		response.Events = append(response.Events, event.EventName())
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+var errEmptyBody = errors.New("empty request body")
+
+func (h *Handler) decode(r *http.Request, name string, payload []byte) (commands.Command, error) {
+	if len(payload) == 0 {
+		return nil, errEmptyBody
+	}
+
+	if !isJSON(r.Header.Get("Content-Type")) {
+		return h.dispatcher.Decode(name, payload)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	return h.dispatcher.DecodeFunc(name, func(v any) error {
+		err := decoder.Decode(v)
+		if errors.Is(err, io.EOF) {
+			return errEmptyBody
+		}
+
+		return err
+	})
+}
+
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
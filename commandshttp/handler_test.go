@@ -0,0 +1,389 @@
+package commandshttp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/commandshttp"
+	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es/storage/inmemory"
+)
+
+type OpenAccount struct {
+	Owner string `json:"owner"`
+}
+
+func (cmd OpenAccount) CommandName() string {
+	return "OpenAccount"
+}
+
+type OpenAccountV1 struct {
+	Name string `json:"name"`
+}
+
+type CloseAccount struct {
+	Reason string `json:"reason"`
+}
+
+func (cmd *CloseAccount) CommandName() string {
+	return "CloseAccount"
+}
+
+type AccountOpened struct {
+	Owner string
+}
+
+func (e AccountOpened) EventName() string {
+	return "AccountOpened"
+}
+
+type AccountClosed struct {
+	Reason string
+}
+
+func (e AccountClosed) EventName() string {
+	return "AccountClosed"
+}
+
+type Account struct {
+	Opened bool
+}
+
+func (a *Account) Handle(ctx context.Context, event es.Event) error {
+	if _, ok := event.Content.(AccountOpened); ok {
+		a.Opened = true
+	}
+
+	return nil
+}
+
+var errAccountClosed = errors.New("account not open")
+
+func newDispatcher(t *testing.T, opts ...commands.Option) *commands.Dispatcher {
+	t.Helper()
+	var storage = inmemory.New()
+	assert.NoError(t, storage.Register("account", AccountOpened{}, AccountClosed{}))
+
+	var dispatcher = commands.New(es.NewStore(storage), opts...)
+	assert.NoError(t, commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error) {
+		return []es.Content{AccountOpened{Owner: cmd.Owner}}, nil
+	}))
+	assert.NoError(t, commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd *CloseAccount, state *Account) ([]es.Content, error) {
+		if !state.Opened {
+			return nil, errAccountClosed
+		}
+		return []es.Content{AccountClosed{Reason: cmd.Reason}}, nil
+	}))
+
+	return dispatcher
+}
+
+func post(t *testing.T, h http.Handler, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	var (
+		w = httptest.NewRecorder()
+		r = httptest.NewRequestWithContext(t.Context(), http.MethodPost, path, strings.NewReader(body))
+	)
+
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	assert.NoError(t, json.NewDecoder(bytes.NewReader(w.Body.Bytes())).Decode(&v))
+	return v
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("dispatch command", func(t *testing.T) {
+		// arrange
+		var sut = commandshttp.NewHandler(newDispatcher(t))
+
+		// act
+		w := post(t, sut, "/account/account-1/OpenAccount", `{"owner": "owner-1"}`)
+
+		// assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		got := decode[commandshttp.Response](t, w)
+		assert.Equal(t, int64(1), got.Position)
+		assert.EqualSlice(t, []string{"AccountOpened"}, got.Events)
+	})
+
+	t.Run("dispatch pointer command", func(t *testing.T) {
+		// arrange
+		var sut = commandshttp.NewHandler(newDispatcher(t))
+		_ = post(t, sut, "/account/account-1/OpenAccount", `{"owner": "owner-1"}`)
+
+		// act
+		w := post(t, sut, "/account/account-1/CloseAccount", `{"reason": "done"}`)
+
+		// assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		got := decode[commandshttp.Response](t, w)
+		assert.Equal(t, int64(2), got.Position)
+		assert.EqualSlice(t, []string{"AccountClosed"}, got.Events)
+	})
+
+	t.Run("dispatch command with router", func(t *testing.T) {
+		// arrange
+		router, err := commands.NewRouter(commands.RouteEntityType("account", newDispatcher(t)))
+		assert.NoError(t, err)
+		var sut = commandshttp.NewHandler(router)
+
+		// act
+		w := post(t, sut, "/account/account-1/OpenAccount", `{"owner": "owner-1"}`)
+
+		// assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		got := decode[commandshttp.Response](t, w)
+		assert.Equal(t, int64(1), got.Position)
+	})
+
+	t.Run("dispatch command without entity type", func(t *testing.T) {
+		// arrange
+		var sut = commandshttp.NewHandler(newDispatcher(t))
+
+		// act
+		w := post(t, sut, "/account-1/OpenAccount", `{"owner": "owner-1"}`)
+
+		// assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		got := decode[commandshttp.Response](t, w)
+		assert.Equal(t, int64(1), got.Position)
+	})
+
+	t.Run("dispatch previous version of command", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+		assert.NoError(t, commands.RegisterUpcast(dispatcher, "OpenAccount.v1", func(from OpenAccountV1) (OpenAccount, error) {
+			return OpenAccount{Owner: from.Name}, nil
+		}))
+		var sut = commandshttp.NewHandler(dispatcher)
+
+		// act
+		w := post(t, sut, "/account/account-1/OpenAccount.v1", `{"name": "owner-1"}`)
+
+		// assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		got := decode[commandshttp.Response](t, w)
+		assert.EqualSlice(t, []string{"AccountOpened"}, got.Events)
+	})
+
+	t.Run("fail with empty body", func(t *testing.T) {
+		// arrange
+		var sut = commandshttp.NewHandler(newDispatcher(t))
+
+		// act
+		w := post(t, sut, "/account/account-1/OpenAccount", ``)
+
+		// assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "bad_request", decode[commandshttp.ErrorResponse](t, w).Error.Code)
+	})
+
+	var errorCases = []struct {
+		name   string
+		opts   []commands.Option
+		hopts  []commandshttp.Option
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{
+			name:   "fail with unknown command",
+			path:   "/account/account-1/Unknown",
+			status: http.StatusNotFound,
+			code:   "not_registered",
+		},
+		{
+			name:   "fail with wrong entity type",
+			path:   "/customer/account-1/OpenAccount",
+			status: http.StatusNotFound,
+			code:   "not_registered",
+		},
+		{
+			name:   "fail with invalid body",
+			path:   "/account/account-1/OpenAccount",
+			body:   `{"owner": 1}`,
+			status: http.StatusBadRequest,
+			code:   "bad_request",
+		},
+		{
+			name:   "fail with unknown fields",
+			path:   "/account/account-1/OpenAccount",
+			body:   `{"name": "owner-1"}`,
+			status: http.StatusBadRequest,
+			code:   "bad_request",
+		},
+		{
+			name:   "fail with executor error",
+			path:   "/account/account-1/CloseAccount",
+			body:   `{}`,
+			status: http.StatusInternalServerError,
+			code:   "internal",
+		},
+		{
+			name:   "fail with mapped executor error",
+			hopts:  []commandshttp.Option{commandshttp.WithErrorStatus(errAccountClosed, http.StatusConflict, "account_closed")},
+			path:   "/account/account-1/CloseAccount",
+			body:   `{}`,
+			status: http.StatusConflict,
+			code:   "account_closed",
+		},
+		{
+			name: "fail with forbidden",
+			opts: []commands.Option{commands.WithMiddlewares(commands.Authorize(commands.PolicyFunc(func(ctx context.Context, actor commands.Actor, commandName, entityType, entityID string) error {
+				return nil
+			})))},
+			path:   "/account/account-1/OpenAccount",
+			body:   `{}`,
+			status: http.StatusForbidden,
+			code:   "forbidden",
+		},
+		{
+			name: "fail with rate limit",
+			opts: []commands.Option{commands.WithMiddlewares(commands.MiddlewareFunc(func(next func(ctx context.Context, command commands.Command) error) func(ctx context.Context, command commands.Command) error {
+				return func(ctx context.Context, command commands.Command) error {
+					return &commands.RateLimitError{Key: "OpenAccount", RetryAfter: 1500 * time.Millisecond}
+				}
+			}))},
+			path:   "/account/account-1/OpenAccount",
+			body:   `{}`,
+			status: http.StatusTooManyRequests,
+			code:   "rate_limited",
+		},
+	}
+
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// arrange
+			var sut = commandshttp.NewHandler(newDispatcher(t, tt.opts...), tt.hopts...)
+
+			// act
+			w := post(t, sut, tt.path, tt.body)
+
+			// assert
+			assert.Equal(t, tt.status, w.Code)
+			got := decode[commandshttp.ErrorResponse](t, w)
+			assert.Equal(t, tt.code, got.Error.Code)
+			assert.Truef(t, got.Error.Message != "", "expected error message")
+		})
+	}
+
+	t.Run("hide internal errors", func(t *testing.T) {
+		// arrange
+		var (
+			reported []error
+			sut      = commandshttp.NewHandler(newDispatcher(t), commandshttp.WithOnError(func(ctx context.Context, err error) {
+				reported = append(reported, err)
+			}))
+		)
+
+		// act
+		w := post(t, sut, "/account/account-1/CloseAccount", `{}`)
+
+		// assert
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		got := decode[commandshttp.ErrorResponse](t, w)
+		assert.Equal(t, "internal", got.Error.Code)
+		assert.Equal(t, http.StatusText(http.StatusInternalServerError), got.Error.Message)
+		if assert.Equal(t, 1, len(reported)) {
+			assert.Truef(t, errors.Is(reported[0], errAccountClosed), "expected executor error, got %v", reported[0])
+		}
+	})
+
+	t.Run("journal rejected requests", func(t *testing.T) {
+		// arrange
+		var (
+			entries []commands.JournalEntry
+			sut     = commandshttp.NewHandler(newDispatcher(t, commands.WithJournal(commands.JournalFunc(func(ctx context.Context, entry commands.JournalEntry) error {
+				entries = append(entries, entry)
+				return nil
+			}))))
+		)
+
+		// act
+		_ = post(t, sut, "/account/account-1/Unknown", `{"owner": "owner-1"}`)
+		_ = post(t, sut, "/account/account-1/OpenAccount", `{"owner": 1}`)
+
+		// assert
+		if assert.Equal(t, 2, len(entries)) {
+			assert.Equal(t, "Unknown", entries[0].CommandName)
+			assert.Equal(t, `{"owner": "owner-1"}`, string(entries[0].Payload))
+			assert.Equal(t, commands.OutcomeRejected, entries[0].Outcome)
+			assert.Equal(t, "OpenAccount", entries[1].CommandName)
+			assert.Equal(t, "account", entries[1].EntityType)
+			assert.Equal(t, "account-1", entries[1].EntityID)
+			assert.Equal(t, `{"owner": 1}`, string(entries[1].Payload))
+			assert.Equal(t, commands.OutcomeRejected, entries[1].Outcome)
+		}
+	})
+
+	t.Run("map errors concurrently", func(t *testing.T) {
+		// arrange
+		var (
+			opts []commandshttp.Option
+			wg   sync.WaitGroup
+		)
+		for i := range 8 {
+			opts = append(opts, commandshttp.WithErrorStatus(fmt.Errorf("error %d", i), http.StatusConflict, "other"))
+		}
+		var sut = commandshttp.NewHandler(newDispatcher(t), append(opts, commandshttp.WithErrorStatus(errAccountClosed, http.StatusConflict, "account_closed"))...)
+
+		// act
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w := post(t, sut, "/account/account-1/CloseAccount", `{}`)
+
+				// assert
+				assert.Equal(t, http.StatusConflict, w.Code)
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("set retry after header", func(t *testing.T) {
+		// arrange
+		var sut = commandshttp.NewHandler(newDispatcher(t, commands.WithMiddlewares(commands.RateLimit(0.5, 1))))
+		_ = post(t, sut, "/account/account-1/OpenAccount", `{}`)
+
+		// act
+		w := post(t, sut, "/account/account-2/OpenAccount", `{}`)
+
+		// assert
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("fail with other methods", func(t *testing.T) {
+		// arrange
+		var (
+			sut = commandshttp.NewHandler(newDispatcher(t))
+			w   = httptest.NewRecorder()
+			r   = httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/account/account-1/OpenAccount", nil)
+		)
+
+		// act
+		sut.ServeHTTP(w, r)
+
+		// assert
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
@@ -0,0 +1,94 @@
+package commandshttp
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kyuff/es-commands/commandsschema"
+)
+
+// OpenAPI describes the commands registered in the Dispatcher as endpoints of the Handler,
+// with the errors they may respond with. Each command is described at the path with its entity type.
+func (h *Handler) OpenAPI(title, version string) *commandsschema.Document {
+	var doc = &commandsschema.Document{
+		OpenAPI: "3.1.0",
+		Info:    commandsschema.Info{Title: title, Version: version},
+		Paths:   make(map[string]*commandsschema.PathItem),
+		Components: commandsschema.Components{
+			Schemas: map[string]*commandsschema.Schema{
+				"Response":      commandsschema.For(reflect.TypeFor[Response]()),
+				"ErrorResponse": commandsschema.For(reflect.TypeFor[ErrorResponse]()),
+			},
+		},
+	}
+
+	var schemas = commandsschema.Commands(h.dispatcher)
+	for _, desc := range h.dispatcher.Commands() {
+		doc.Components.Schemas[desc.Name] = schemas[desc.Name]
+		doc.Paths["/"+desc.EntityType+"/{entityID}/"+desc.Name] = &commandsschema.PathItem{
+			Post: &commandsschema.Operation{
+				OperationID: desc.Name,
+				Summary:     "Dispatch " + desc.Name + " to an entity of type " + desc.EntityType,
+				Tags:        []string{desc.EntityType},
+				Parameters: []commandsschema.Parameter{{
+					Name:     "entityID",
+					In:       "path",
+					Required: true,
+					Schema:   &commandsschema.Schema{Type: "string"},
+				}},
+				RequestBody: &commandsschema.RequestBody{
+					Required: true,
+					Content: map[string]commandsschema.MediaType{
+						"application/json": {Schema: commandsschema.Ref(desc.Name)},
+					},
+				},
+				Responses: h.responses(),
+			},
+		}
+	}
+
+	return doc
+}
+
+// responses of a command endpoint, with the error codes grouped by status.
+func (h *Handler) responses() map[string]*commandsschema.Response {
+	var responses = map[string]*commandsschema.Response{
+		strconv.Itoa(http.StatusOK): {
+			Description: "The command was executed",
+			Content: map[string]commandsschema.MediaType{
+				"application/json": {Schema: commandsschema.Ref("Response")},
+			},
+		},
+	}
+
+	var codes = make(map[int][]string)
+	for _, m := range slices.Concat(h.mappings, errorMappings) {
+		if !slices.Contains(codes[m.status], m.code) {
+			codes[m.status] = append(codes[m.status], m.code)
+		}
+	}
+	codes[http.StatusInternalServerError] = append(codes[http.StatusInternalServerError], "internal")
+
+	for status, statusCodes := range codes {
+		var response = &commandsschema.Response{
+			Description: "Error codes: " + strings.Join(statusCodes, ", "),
+			Content: map[string]commandsschema.MediaType{
+				"application/json": {Schema: commandsschema.Ref("ErrorResponse")},
+			},
+		}
+		if status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable {
+			response.Headers = map[string]*commandsschema.Header{
+				"Retry-After": {
+					Description: "Seconds to wait before retrying",
+					Schema:      &commandsschema.Schema{Type: "integer"},
+				},
+			}
+		}
+		responses[strconv.Itoa(status)] = response
+	}
+
+	return responses
+}
@@ -0,0 +1,73 @@
+package commandshttp_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/kyuff/es-commands/commandshttp"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestHandler_OpenAPI(t *testing.T) {
+	t.Run("describe command endpoints", func(t *testing.T) {
+		// arrange
+		var sut = commandshttp.NewHandler(newDispatcher(t))
+
+		// act
+		got := sut.OpenAPI("accounts", "1.0.0")
+
+		// assert
+		assert.Equal(t, "3.1.0", got.OpenAPI)
+		assert.Equal(t, "accounts", got.Info.Title)
+		assert.Equal(t, 2, len(got.Paths))
+		op := got.Paths["/account/{entityID}/OpenAccount"].Post
+		assert.Equal(t, "OpenAccount", op.OperationID)
+		assert.EqualSlice(t, []string{"account"}, op.Tags)
+		assert.Equal(t, "entityID", op.Parameters[0].Name)
+		assert.Equal(t, "path", op.Parameters[0].In)
+		assert.Equal(t, "#/components/schemas/OpenAccount", op.RequestBody.Content["application/json"].Schema.Ref)
+		assert.Equal(t, "#/components/schemas/Response", op.Responses["200"].Content["application/json"].Schema.Ref)
+		assert.Equal(t, "string", got.Components.Schemas["OpenAccount"].Properties["owner"].Type)
+		assert.Equal(t, "string", got.Components.Schemas["CloseAccount"].Properties["reason"].Type)
+		assert.Equal(t, "integer", got.Components.Schemas["Response"].Properties["position"].Type)
+		assert.Truef(t, got.Paths["/account/{entityID}/CloseAccount"] != nil, "missing CloseAccount")
+	})
+
+	t.Run("describe error responses", func(t *testing.T) {
+		// arrange
+		var sut = commandshttp.NewHandler(newDispatcher(t),
+			commandshttp.WithErrorStatus(errAccountClosed, http.StatusConflict, "account_closed"),
+		)
+
+		// act
+		got := sut.OpenAPI("accounts", "1.0.0")
+
+		// assert
+		responses := got.Paths["/account/{entityID}/CloseAccount"].Post.Responses
+		for status, code := range map[string]string{
+			"400": "bad_request",
+			"404": "not_registered",
+			"409": "account_closed",
+			"429": "rate_limited",
+			"500": "internal",
+			"504": "timeout",
+		} {
+			assert.Equalf(t, "Error codes: "+code, responses[status].Description, "status %s", status)
+			assert.Equal(t, "#/components/schemas/ErrorResponse", responses[status].Content["application/json"].Schema.Ref)
+		}
+		assert.Truef(t, responses["429"].Headers["Retry-After"] != nil, "missing Retry-After")
+	})
+
+	t.Run("marshal as json", func(t *testing.T) {
+		// arrange
+		var sut = commandshttp.NewHandler(newDispatcher(t))
+
+		// act
+		data, err := json.Marshal(sut.OpenAPI("accounts", "1.0.0"))
+
+		// assert
+		assert.NoError(t, err)
+		assert.Match(t, `"OpenAccount":\{"title":"OpenAccount","type":"object",.*"additionalProperties":false\}`, string(data))
+	})
+}
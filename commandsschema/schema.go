@@ -0,0 +1,242 @@
+// Package commandsschema describes registered commands with JSON Schema and OpenAPI.
+package commandsschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	commands "github.com/kyuff/es-commands"
+)
+
+// Schema is a JSON Schema (draft 2020-12), limited to what is needed to describe commands.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	// closed marks an object without additional properties.
+	closed bool
+}
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type schema Schema
+	if !s.closed {
+		return json.Marshal((*schema)(s))
+	}
+
+	return json.Marshal(struct {
+		*schema
+		AdditionalProperties bool `json:"additionalProperties"`
+	}{schema: (*schema)(s)})
+}
+
+// Commands returns a Schema of each command registered in the Dispatcher by name.
+// Commands are described by their JSON encoding, as decoded by the HTTP transport.
+func Commands(dispatcher commands.Registry) map[string]*Schema {
+	var schemas = make(map[string]*Schema)
+	for _, desc := range dispatcher.Commands() {
+		schema := For(desc.CommandType)
+		schema.Title = desc.Name
+		schema.closed = schema.Type == "object"
+		schemas[desc.Name] = schema
+	}
+
+	return schemas
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+)
+
+// For returns the Schema of the JSON encoding of values of the type. Constraints are read
+// from `validate` tags on struct fields: required, min, max, len, oneof, email, url and uuid.
+func For(typ reflect.Type) *Schema {
+	return schemaFor(typ, make(map[reflect.Type]bool))
+}
+
+func schemaFor(typ reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case typ.Implements(jsonMarshalerType) || reflect.PointerTo(typ).Implements(jsonMarshalerType):
+		return &Schema{}
+	case typ.Implements(textMarshalerType) || reflect.PointerTo(typ).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &Schema{Type: "array", Items: schemaFor(typ.Elem(), visiting)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaFor(typ.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[typ] {
+			// recursive types are left open
+			return &Schema{Type: "object"}
+		}
+		visiting[typ] = true
+		defer delete(visiting, typ)
+
+		var schema = &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addFields(schema, typ, visiting)
+		return schema
+	default:
+		return &Schema{}
+	}
+}
+
+// addFields of the struct type to the schema, following the rules of encoding/json.
+func addFields(schema *Schema, typ reflect.Type, visiting map[reflect.Type]bool) {
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, omit := jsonName(field)
+		if omit {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			addFields(schema, fieldType, visiting)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := schemaFor(field.Type, visiting)
+		if constrain(property, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return "", false
+	}
+	if tag == "-" {
+		return "", true
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	return name, false
+}
+
+// constrain the schema with the rules in a validate tag, returning whether the field is required.
+func constrain(schema *Schema, tag string) bool {
+	var required bool
+	for rule := range strings.SplitSeq(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "uuid":
+			schema.Format = "uuid"
+		case "oneof":
+			for option := range strings.FieldsSeq(value) {
+				schema.Enum = append(schema.Enum, enumValue(schema.Type, option))
+			}
+		case "min":
+			bound(schema, value, true)
+		case "max":
+			bound(schema, value, false)
+		case "len":
+			bound(schema, value, true)
+			bound(schema, value, false)
+		}
+	}
+
+	return required
+}
+
+// bound sets the lower or upper limit that fits the type of the schema.
+func bound(schema *Schema, value string, lower bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+
+	var count = int(n)
+	switch schema.Type {
+	case "string":
+		if lower {
+			schema.MinLength = &count
+		} else {
+			schema.MaxLength = &count
+		}
+	case "array":
+		if lower {
+			schema.MinItems = &count
+		} else {
+			schema.MaxItems = &count
+		}
+	case "integer", "number":
+		if lower {
+			schema.Minimum = &n
+		} else {
+			schema.Maximum = &n
+		}
+	}
+}
+
+func enumValue(typ, value string) any {
+	switch typ {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,113 @@
+package commandsschema_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/commandsschema"
+	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es/storage/inmemory"
+)
+
+type Address struct {
+	Street string `json:"street" validate:"required"`
+}
+
+type Base struct {
+	Reference string `json:"reference" validate:"uuid"`
+}
+
+type OpenAccount struct {
+	Base
+	Owner    string            `json:"owner" validate:"required,min=2,max=40"`
+	Email    string            `json:"email,omitempty" validate:"email"`
+	Currency string            `json:"currency" validate:"required,oneof=EUR USD"`
+	Deposit  float64           `json:"deposit" validate:"min=0"`
+	Limit    *int              `json:"limit" validate:"oneof=100 200"`
+	Tags     []string          `json:"tags" validate:"max=3"`
+	Address  Address           `json:"address"`
+	Labels   map[string]string `json:"labels"`
+	OpenedAt time.Time         `json:"opened_at"`
+	Avatar   []byte            `json:"avatar"`
+	Ignored  string            `json:"-"`
+	Untagged bool
+	internal string
+}
+
+func (cmd OpenAccount) CommandName() string {
+	return "OpenAccount"
+}
+
+type Node struct {
+	Name     string `json:"name"`
+	Children []Node `json:"children"`
+}
+
+type Account struct{}
+
+func (a *Account) Handle(ctx context.Context, event es.Event) error {
+	return nil
+}
+
+func marshal(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestFor(t *testing.T) {
+	t.Run("describe struct with constraints from tags", func(t *testing.T) {
+		// act
+		var got = commandsschema.For(reflect.TypeFor[OpenAccount]())
+
+		// assert
+		assert.Equal(t, `{"type":"object","properties":{`+
+			`"Untagged":{"type":"boolean"},`+
+			`"address":{"type":"object","properties":{"street":{"type":"string"}},"required":["street"]},`+
+			`"avatar":{"type":"string","contentEncoding":"base64"},`+
+			`"currency":{"type":"string","enum":["EUR","USD"]},`+
+			`"deposit":{"type":"number","minimum":0},`+
+			`"email":{"type":"string","format":"email"},`+
+			`"labels":{"type":"object","additionalProperties":{"type":"string"}},`+
+			`"limit":{"type":"integer","enum":[100,200]},`+
+			`"opened_at":{"type":"string","format":"date-time"},`+
+			`"owner":{"type":"string","minLength":2,"maxLength":40},`+
+			`"reference":{"type":"string","format":"uuid"},`+
+			`"tags":{"type":"array","items":{"type":"string"},"maxItems":3}},`+
+			`"required":["owner","currency"]}`, marshal(t, got))
+	})
+
+	t.Run("describe recursive types", func(t *testing.T) {
+		// act
+		var got = commandsschema.For(reflect.TypeFor[*Node]())
+
+		// assert
+		assert.Equal(t, `{"type":"object","properties":{`+
+			`"children":{"type":"array","items":{"type":"object"}},`+
+			`"name":{"type":"string"}}}`, marshal(t, got))
+	})
+}
+
+func TestCommands(t *testing.T) {
+	t.Run("describe registered commands", func(t *testing.T) {
+		// arrange
+		var dispatcher = commands.NewDispatcher(es.NewStore(inmemory.New()))
+		assert.NoError(t, commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error) {
+			return nil, nil
+		}))
+
+		// act
+		var got = commandsschema.Commands(dispatcher)
+
+		// assert
+		assert.Equal(t, 1, len(got))
+		assert.Equal(t, "OpenAccount", got["OpenAccount"].Title)
+		assert.Match(t, `^\{"title":"OpenAccount","type":"object",.*"additionalProperties":false\}$`, marshal(t, got["OpenAccount"]))
+	})
+}
@@ -0,0 +1,86 @@
+package commandssql_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es/storage/inmemory"
+	_ "modernc.org/sqlite"
+)
+
+func newDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "commands.db")+"?_pragma=busy_timeout(5000)")
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
+
+type clock struct {
+	now time.Time
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+type OpenAccount struct {
+	Owner string
+}
+
+func (cmd OpenAccount) CommandName() string {
+	return "OpenAccount"
+}
+
+type AccountOpened struct {
+	Owner string
+}
+
+func (e AccountOpened) EventName() string {
+	return "AccountOpened"
+}
+
+type Account struct {
+	Owner string
+}
+
+func (a *Account) Handle(ctx context.Context, event es.Event) error {
+	if e, ok := event.Content.(AccountOpened); ok {
+		a.Owner = e.Owner
+	}
+
+	return nil
+}
+
+func newStore(t *testing.T) *es.Store {
+	t.Helper()
+	var storage = inmemory.New()
+	assert.NoError(t, storage.Register("account", AccountOpened{}))
+
+	return es.NewStore(storage)
+}
+
+func newDispatcher(t *testing.T, executor func(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error), opts ...commands.Option) *commands.Dispatcher {
+	t.Helper()
+	var dispatcher = commands.New(newStore(t), opts...)
+	assert.NoError(t, commands.RegisterFunc(dispatcher, "account", executor))
+
+	return dispatcher
+}
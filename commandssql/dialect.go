@@ -0,0 +1,47 @@
+// Package commandssql has database/sql implementations of the commands infrastructure.
+package commandssql
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect adapts the queries to a database.
+type Dialect struct {
+	// Placeholder returns the bind parameter for the n'th argument, starting at 1.
+	Placeholder func(n int) string
+	// Blob is the column type for binary data.
+	Blob string
+}
+
+var SQLite = Dialect{
+	Placeholder: func(n int) string {
+		return "?"
+	},
+	Blob: "BLOB",
+}
+
+var Postgres = Dialect{
+	Placeholder: func(n int) string {
+		return "$" + strconv.Itoa(n)
+	},
+	Blob: "BYTEA",
+}
+
+// rebind replaces ? in the query with the placeholders of the dialect.
+func (d Dialect) rebind(query string) string {
+	var (
+		b strings.Builder
+		n = 0
+	)
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(d.Placeholder(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
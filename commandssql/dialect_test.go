@@ -0,0 +1,19 @@
+package commandssql
+
+import (
+	"testing"
+
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestDialect(t *testing.T) {
+	var query = `SELECT a FROM t WHERE b = ? AND c = ?`
+
+	t.Run("sqlite", func(t *testing.T) {
+		assert.Equal(t, `SELECT a FROM t WHERE b = ? AND c = ?`, SQLite.rebind(query))
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		assert.Equal(t, `SELECT a FROM t WHERE b = $1 AND c = $2`, Postgres.rebind(query))
+	})
+}
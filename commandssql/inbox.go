@@ -0,0 +1,89 @@
+package commandssql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	commands "github.com/kyuff/es-commands"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Outcome of a command sent to an Inbox.
+type Outcome struct {
+	ID       string
+	Status   Status
+	Attempts int
+	// Error is the last error the command failed with.
+	Error string
+}
+
+// NewInbox creates an Inbox. It accepts the same options as NewSource, with the table
+// defaulting to commands_inbox.
+func NewInbox(db *sql.DB, opts ...SourceOption) *Inbox {
+	return &Inbox{
+		Source: NewSource(db, append([]SourceOption{WithSourceTable("commands_inbox")}, opts...)...),
+	}
+}
+
+// Inbox is a Source that keeps the commands after they are dispatched, recording their
+// Outcome and deduplicating them by the ID of the Envelope.
+type Inbox struct {
+	*Source
+}
+
+// Send persists the Envelope. Sending an Envelope with an ID already in the Inbox is a no-op,
+// so the ID should be chosen by the caller, typically from an idempotency key.
+func (i *Inbox) Send(ctx context.Context, env commands.Envelope) error {
+	return i.insert(ctx, env, i.now(), "ON CONFLICT (id) DO NOTHING")
+}
+
+// Ack marks the command as done.
+func (i *Inbox) Ack(ctx context.Context, env commands.Envelope) error {
+	_, err := i.db.ExecContext(ctx, i.dialect.rebind(fmt.Sprintf(`
+UPDATE %s SET status = ?, available_at = ?, last_error = ''
+WHERE id = ?`, i.table)),
+		StatusDone, i.now().UnixNano(), env.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("ack %s: %w", env.ID, err)
+	}
+
+	return nil
+}
+
+// Outcome returns the state of the command with the given ID.
+func (i *Inbox) Outcome(ctx context.Context, id string) (Outcome, error) {
+	var outcome Outcome
+	err := i.db.QueryRowContext(ctx, i.dialect.rebind(fmt.Sprintf(`
+SELECT id, status, attempts, last_error
+FROM %s
+WHERE id = ?`, i.table)),
+		id,
+	).Scan(&outcome.ID, &outcome.Status, &outcome.Attempts, &outcome.Error)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Outcome{}, fmt.Errorf("outcome %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return Outcome{}, fmt.Errorf("outcome %s: %w", id, err)
+	}
+
+	return outcome, nil
+}
+
+// Purge deletes the commands that were done before the given time. Commands are
+// only deduplicated while they are in the Inbox.
+func (i *Inbox) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := i.db.ExecContext(ctx, i.dialect.rebind(fmt.Sprintf(`
+DELETE FROM %s WHERE status = ? AND available_at < ?`, i.table)),
+		StatusDone, before.UnixNano(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("purge: %w", err)
+	}
+
+	return res.RowsAffected()
+}
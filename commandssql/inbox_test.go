@@ -0,0 +1,179 @@
+package commandssql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/commandssql"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestInbox(t *testing.T) {
+	var (
+		newInbox = func(t *testing.T, opts ...commandssql.SourceOption) *commandssql.Inbox {
+			var inbox = commandssql.NewInbox(newDB(t), append([]commandssql.SourceOption{
+				commandssql.WithPollInterval(time.Millisecond),
+			}, opts...)...)
+			assert.NoError(t, inbox.Migrate(t.Context()))
+			return inbox
+		}
+		envelope = commands.Envelope{
+			ID:          "envelope-1",
+			CommandName: "OpenAccount",
+			EntityID:    "account-1",
+			Payload:     []byte(`{"Owner": "owner-1"}`),
+		}
+	)
+
+	t.Run("deduplicate pending command", func(t *testing.T) {
+		// arrange
+		var sut = newInbox(t)
+		assert.NoError(t, sut.Send(t.Context(), envelope))
+
+		// act
+		err := sut.Send(t.Context(), envelope)
+
+		// assert
+		assert.NoError(t, err)
+		got, err := sut.Receive(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, envelope.ID, got.ID)
+		assertEmpty(t, sut.Source)
+	})
+
+	t.Run("record done outcome", func(t *testing.T) {
+		// arrange
+		var sut = newInbox(t)
+		assert.NoError(t, sut.Send(t.Context(), envelope))
+		got, _ := sut.Receive(t.Context())
+
+		// act
+		err := sut.Ack(t.Context(), got)
+
+		// assert
+		assert.NoError(t, err)
+		outcome, err := sut.Outcome(t.Context(), envelope.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, commandssql.StatusDone, outcome.Status)
+		assert.Equal(t, "", outcome.Error)
+	})
+
+	t.Run("deduplicate done command", func(t *testing.T) {
+		// arrange
+		var (
+			clock = newClock()
+			sut   = newInbox(t, commandssql.WithSourceClock(clock.Now))
+		)
+		assert.NoError(t, sut.Send(t.Context(), envelope))
+		got, _ := sut.Receive(t.Context())
+		assert.NoError(t, sut.Ack(t.Context(), got))
+
+		// act
+		err := sut.Send(t.Context(), envelope)
+
+		// assert
+		assert.NoError(t, err)
+		clock.Advance(time.Hour)
+		assertEmpty(t, sut.Source)
+	})
+
+	t.Run("record failed outcome", func(t *testing.T) {
+		// arrange
+		var sut = newInbox(t)
+		assert.NoError(t, sut.Send(t.Context(), envelope))
+		got, _ := sut.Receive(t.Context())
+
+		// act
+		err := sut.DeadLetter(t.Context(), got, errors.New("executor-error"))
+
+		// assert
+		assert.NoError(t, err)
+		outcome, err := sut.Outcome(t.Context(), envelope.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, commandssql.StatusDead, outcome.Status)
+		assert.Equal(t, "executor-error", outcome.Error)
+		assert.Equal(t, 1, outcome.Attempts)
+	})
+
+	t.Run("fail outcome of unknown command", func(t *testing.T) {
+		// arrange
+		var sut = newInbox(t)
+
+		// act
+		_, err := sut.Outcome(t.Context(), "unknown")
+
+		// assert
+		assert.Truef(t, errors.Is(err, commandssql.ErrNotFound), "expected ErrNotFound, got %v", err)
+	})
+
+	t.Run("purge done commands", func(t *testing.T) {
+		// arrange
+		var (
+			clock = newClock()
+			sut   = newInbox(t, commandssql.WithSourceClock(clock.Now))
+			other = envelope
+		)
+		other.ID = "envelope-2"
+		assert.NoError(t, sut.Send(t.Context(), envelope))
+		assert.NoError(t, sut.Send(t.Context(), other))
+		got, _ := sut.Receive(t.Context())
+		assert.NoError(t, sut.Ack(t.Context(), got))
+
+		// act
+		purged, err := sut.Purge(t.Context(), clock.Now().Add(time.Second))
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+		_, err = sut.Outcome(t.Context(), got.ID)
+		assert.Truef(t, errors.Is(err, commandssql.ErrNotFound), "expected ErrNotFound, got %v", err)
+		_, err = sut.Outcome(t.Context(), "envelope-2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("dispatch until done with consumer", func(t *testing.T) {
+		// arrange
+		var (
+			inbox      = newInbox(t, commandssql.WithRetryDelay(time.Millisecond))
+			calls      = 0
+			done       = make(chan struct{})
+			dispatcher = newDispatcher(t, func(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error) {
+				calls++
+				if calls == 1 {
+					return nil, errors.New("executor-error")
+				}
+
+				return []es.Content{AccountOpened{Owner: cmd.Owner}}, nil
+			})
+			consumer    = commands.NewConsumer(dispatcher, inbox)
+			ctx, cancel = context.WithCancel(t.Context())
+		)
+
+		env, err := dispatcher.Envelope(t.Context(), "account-1", OpenAccount{Owner: "owner-1"})
+		assert.NoError(t, err)
+
+		go func() {
+			_ = consumer.Run(ctx)
+			close(done)
+		}()
+
+		// act
+		assert.NoError(t, inbox.Send(t.Context(), env))
+
+		// assert
+		var outcome commandssql.Outcome
+		for outcome.Status != commandssql.StatusDone {
+			time.Sleep(time.Millisecond)
+			outcome, err = inbox.Outcome(t.Context(), env.ID)
+			assert.NoError(t, err)
+		}
+		cancel()
+		<-done
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, 1, outcome.Attempts)
+	})
+}
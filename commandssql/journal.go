@@ -0,0 +1,161 @@
+package commandssql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	commands "github.com/kyuff/es-commands"
+)
+
+var _ commands.Journal = (*Journal)(nil)
+
+type JournalOption func(j *Journal)
+
+// WithJournalTable sets the table the entries are stored in. Defaults to commands_journal.
+func WithJournalTable(table string) JournalOption {
+	return func(j *Journal) {
+		j.table = table
+	}
+}
+
+func WithJournalDialect(dialect Dialect) JournalOption {
+	return func(j *Journal) {
+		j.dialect = dialect
+	}
+}
+
+// NewJournal creates a commands.Journal that stores the entries in a table.
+func NewJournal(db *sql.DB, opts ...JournalOption) *Journal {
+	var j = &Journal{
+		db:      db,
+		table:   "commands_journal",
+		dialect: SQLite,
+	}
+
+	for _, opt := range opts {
+		opt(j)
+	}
+
+	return j
+}
+
+type Journal struct {
+	db      *sql.DB
+	table   string
+	dialect Dialect
+}
+
+// Migrate creates the table if it does not exist.
+func (j *Journal) Migrate(ctx context.Context) error {
+	_, err := j.db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	id            VARCHAR(36) PRIMARY KEY,
+	command_name  VARCHAR(255) NOT NULL,
+	entity_type   VARCHAR(255) NOT NULL,
+	entity_id     VARCHAR(255) NOT NULL,
+	payload       %s,
+	payload_error TEXT NOT NULL,
+	metadata      TEXT NOT NULL,
+	outcome       VARCHAR(32) NOT NULL,
+	error         TEXT NOT NULL,
+	error_kind    VARCHAR(255) NOT NULL,
+	recorded_at   BIGINT NOT NULL,
+	duration      BIGINT NOT NULL,
+	position      BIGINT NOT NULL,
+	events        TEXT NOT NULL
+)`, j.table, j.dialect.Blob))
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", j.table, err)
+	}
+
+	_, err = j.db.ExecContext(ctx, fmt.Sprintf(`
+CREATE INDEX IF NOT EXISTS %s_entity ON %s (entity_type, entity_id, recorded_at)`, j.table, j.table))
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", j.table, err)
+	}
+
+	return nil
+}
+
+func (j *Journal) Record(ctx context.Context, entry commands.JournalEntry) error {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+
+	metadata, err := marshalMetadata(entry.Metadata)
+	if err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+
+	events, err := json.Marshal(entry.Events)
+	if err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+
+	_, err = j.db.ExecContext(ctx, j.dialect.rebind(fmt.Sprintf(`
+INSERT INTO %s (id, command_name, entity_type, entity_id, payload, payload_error, metadata, outcome, error, error_kind, recorded_at, duration, position, events)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, j.table)),
+		id.String(), entry.CommandName, entry.EntityType, entry.EntityID, entry.Payload, entry.PayloadError, metadata,
+		string(entry.Outcome), entry.Error, entry.ErrorKind, entry.Time.UnixNano(), int64(entry.Duration), entry.Position, string(events),
+	)
+	if err != nil {
+		return fmt.Errorf("journal %s: %w", entry.CommandName, err)
+	}
+
+	return nil
+}
+
+// Entries returns the entries of the commands dispatched to the entity, oldest first.
+// Commands that are not registered have an empty entity type.
+func (j *Journal) Entries(ctx context.Context, entityType, entityID string) ([]commands.JournalEntry, error) {
+	rows, err := j.db.QueryContext(ctx, j.dialect.rebind(fmt.Sprintf(`
+SELECT command_name, entity_type, entity_id, payload, payload_error, metadata, outcome, error, error_kind, recorded_at, duration, position, events
+FROM %s
+WHERE entity_type = ? AND entity_id = ?
+ORDER BY recorded_at, id`, j.table)),
+		entityType, entityID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("journal: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var entries []commands.JournalEntry
+	for rows.Next() {
+		var (
+			entry      commands.JournalEntry
+			metadata   string
+			events     string
+			recordedAt int64
+			duration   int64
+		)
+		err = rows.Scan(&entry.CommandName, &entry.EntityType, &entry.EntityID, &entry.Payload, &entry.PayloadError, &metadata,
+			&entry.Outcome, &entry.Error, &entry.ErrorKind, &recordedAt, &duration, &entry.Position, &events)
+		if err != nil {
+			return nil, fmt.Errorf("journal: %w", err)
+		}
+
+		err = json.Unmarshal([]byte(metadata), &entry.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("journal: %w", err)
+		}
+
+		err = json.Unmarshal([]byte(events), &entry.Events)
+		if err != nil {
+			return nil, fmt.Errorf("journal: %w", err)
+		}
+
+		entry.Time = time.Unix(0, recordedAt)
+		entry.Duration = time.Duration(duration)
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
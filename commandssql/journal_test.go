@@ -0,0 +1,84 @@
+package commandssql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/commandssql"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestJournal(t *testing.T) {
+	var (
+		newJournal = func(t *testing.T) *commandssql.Journal {
+			var journal = commandssql.NewJournal(newDB(t))
+			assert.NoError(t, journal.Migrate(t.Context()))
+			return journal
+		}
+		errOwner = errors.New("no owner")
+		executor = func(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error) {
+			if cmd.Owner == "" {
+				return nil, errOwner
+			}
+			return []es.Content{AccountOpened{Owner: cmd.Owner}}, nil
+		}
+	)
+
+	t.Run("record dispatched commands", func(t *testing.T) {
+		// arrange
+		var (
+			journal    = newJournal(t)
+			dispatcher = newDispatcher(t, executor, commands.WithJournal(journal))
+			ctx        = commands.ContextWithMetadata(t.Context(), commands.Metadata{"user": "user-1"})
+		)
+		assert.NoError(t, dispatcher.Dispatch(ctx, "account-1", OpenAccount{Owner: "owner-1"}))
+		assert.Error(t, dispatcher.Dispatch(ctx, "account-1", OpenAccount{}))
+
+		// act
+		got, err := journal.Entries(t.Context(), "account", "account-1")
+
+		// assert
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(got)) {
+			assert.Equal(t, "OpenAccount", got[0].CommandName)
+			assert.Equal(t, "account", got[0].EntityType)
+			assert.Equal(t, `{"Owner":"owner-1"}`, string(got[0].Payload))
+			assert.Equal(t, "", got[0].PayloadError)
+			assert.Equal(t, "user-1", got[0].Metadata["user"])
+			assert.Equal(t, commands.OutcomeSucceeded, got[0].Outcome)
+			assert.Equal(t, "", got[0].Error)
+			assert.Equal(t, int64(1), got[0].Position)
+			assert.EqualSlice(t, []string{"AccountOpened"}, got[0].Events)
+			assert.Truef(t, !got[0].Time.IsZero(), "expected time")
+
+			assert.Equal(t, commands.OutcomeRejected, got[1].Outcome)
+			assert.Equal(t, "no owner", got[1].Error)
+			assert.Equal(t, "*errors.errorString", got[1].ErrorKind)
+			assert.Equal(t, 0, len(got[1].Events))
+		}
+	})
+
+	t.Run("record commands that are not registered", func(t *testing.T) {
+		// arrange
+		var (
+			journal    = newJournal(t)
+			dispatcher = commands.New(nil, commands.WithJournal(journal))
+		)
+		err := dispatcher.Dispatch(t.Context(), "account-1", OpenAccount{Owner: "owner-1"})
+		assert.Truef(t, errors.Is(err, commands.ErrNotRegistered), "expected ErrNotRegistered, got %v", err)
+
+		// act
+		got, err := journal.Entries(t.Context(), "", "account-1")
+
+		// assert
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, "OpenAccount", got[0].CommandName)
+			assert.Equal(t, commands.OutcomeRejected, got[0].Outcome)
+			assert.Equal(t, `{"Owner":"owner-1"}`, string(got[0].Payload))
+		}
+	})
+}
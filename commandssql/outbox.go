@@ -0,0 +1,260 @@
+package commandssql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+)
+
+var (
+	_ commands.Publisher = (*Outbox)(nil)
+	_ es.Handler         = (*Outbox)(nil)
+)
+
+// Message is an event in the Outbox.
+type Message struct {
+	Tenant      string
+	EntityType  string
+	EntityID    string
+	EventNumber int64
+	EventName   string
+	// CommandName and Metadata are empty for events that were only read from the event log.
+	CommandName string
+	Payload     []byte
+	Metadata    commands.Metadata
+}
+
+type OutboxOption func(o *Outbox)
+
+// WithOutboxTable sets the table the messages are stored in. Defaults to commands_outbox.
+func WithOutboxTable(table string) OutboxOption {
+	return func(o *Outbox) {
+		o.table = table
+	}
+}
+
+func WithOutboxDialect(dialect Dialect) OutboxOption {
+	return func(o *Outbox) {
+		o.dialect = dialect
+	}
+}
+
+// WithOutboxCodec sets the Codec used for the payload of the events. Defaults to commands.JSONCodec.
+func WithOutboxCodec(codec commands.Codec) OutboxOption {
+	return func(o *Outbox) {
+		o.codec = codec
+	}
+}
+
+func WithOutboxClock(now func() time.Time) OutboxOption {
+	return func(o *Outbox) {
+		o.now = now
+	}
+}
+
+// NewOutbox creates a commands.Publisher that stores the events in a table, to be sent by a Relay.
+//
+// Publishing happens after the events are committed to the Store, so the Outbox should also be
+// subscribed to the Store as an es.Handler. It then reads the events from the event log, so
+// events of commands that failed to publish them still reach the Relay. The streams of tenants
+// are subscribed to with the handler of the Tenant, so their events match those published.
+func NewOutbox(db *sql.DB, opts ...OutboxOption) *Outbox {
+	var o = &Outbox{
+		db:      db,
+		table:   "commands_outbox",
+		dialect: SQLite,
+		codec:   commands.JSONCodec{},
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+type Outbox struct {
+	db      *sql.DB
+	table   string
+	dialect Dialect
+	codec   commands.Codec
+	now     func() time.Time
+}
+
+// Migrate creates the table if it does not exist.
+func (o *Outbox) Migrate(ctx context.Context) error {
+	_, err := o.db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	tenant       VARCHAR(255) NOT NULL,
+	entity_type  VARCHAR(255) NOT NULL,
+	entity_id    VARCHAR(255) NOT NULL,
+	event_number BIGINT NOT NULL,
+	event_name   VARCHAR(255) NOT NULL,
+	command_name VARCHAR(255) NOT NULL,
+	payload      %s NOT NULL,
+	metadata     TEXT NOT NULL,
+	status       VARCHAR(16) NOT NULL,
+	created_at   BIGINT NOT NULL,
+	PRIMARY KEY (tenant, entity_type, entity_id, event_number)
+)`, o.table, o.dialect.Blob))
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", o.table, err)
+	}
+
+	return nil
+}
+
+// Publish stores the events of the Publication in a single transaction. Events already
+// read from the event log are given the command name and Metadata, and events already
+// sent are ignored.
+func (o *Outbox) Publish(ctx context.Context, pub commands.Publication) (err error) {
+	metadata, err := marshalMetadata(pub.Metadata)
+	if err != nil {
+		return fmt.Errorf("outbox: %w", err)
+	}
+
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("outbox: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var (
+		createdAt = o.now().UnixNano()
+		query     = o.dialect.rebind(fmt.Sprintf(`
+INSERT INTO %[1]s (tenant, entity_type, entity_id, event_number, event_name, command_name, payload, metadata, status, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (tenant, entity_type, entity_id, event_number) DO UPDATE
+SET command_name = excluded.command_name, metadata = excluded.metadata
+WHERE %[1]s.status = ?`, o.table))
+	)
+	for i, event := range pub.Events {
+		payload, err := o.codec.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("outbox %s: %w", event.EventName(), err)
+		}
+
+		_, err = tx.ExecContext(ctx, query,
+			pub.Tenant, pub.EntityType, pub.EntityID, pub.EventNumber(i), event.EventName(), pub.CommandName, payload, metadata, StatusPending, createdAt,
+			StatusPending,
+		)
+		if err != nil {
+			return fmt.Errorf("outbox %s: %w", event.EventName(), err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("outbox: %w", err)
+	}
+
+	return nil
+}
+
+// Handle stores an event read from the event log of streams without a tenant.
+// Events already in the Outbox are ignored.
+func (o *Outbox) Handle(ctx context.Context, event es.Event) error {
+	return o.handle(ctx, "", event)
+}
+
+// Tenant returns an es.Handler that stores the events read from the event log of the tenant.
+func (o *Outbox) Tenant(tenant string) es.Handler {
+	return es.HandlerFunc(func(ctx context.Context, event es.Event) error {
+		return o.handle(ctx, tenant, event)
+	})
+}
+
+func (o *Outbox) handle(ctx context.Context, tenant string, event es.Event) error {
+	payload, err := o.codec.Marshal(event.Content)
+	if err != nil {
+		return fmt.Errorf("outbox %s: %w", event.Content.EventName(), err)
+	}
+
+	_, err = o.db.ExecContext(ctx, o.dialect.rebind(fmt.Sprintf(`
+INSERT INTO %s (tenant, entity_type, entity_id, event_number, event_name, command_name, payload, metadata, status, created_at)
+VALUES (?, ?, ?, ?, ?, '', ?, 'null', ?, ?)
+ON CONFLICT (tenant, entity_type, entity_id, event_number) DO NOTHING`, o.table)),
+		tenant, event.EntityType, event.EntityID, event.EventNumber, event.Content.EventName(), payload, StatusPending, o.now().UnixNano(),
+	)
+	if err != nil {
+		return fmt.Errorf("outbox %s: %w", event.Content.EventName(), err)
+	}
+
+	return nil
+}
+
+// Purge deletes the messages that were sent and stored before the given time. Events are
+// only deduplicated while they are in the Outbox.
+func (o *Outbox) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := o.db.ExecContext(ctx, o.dialect.rebind(fmt.Sprintf(`
+DELETE FROM %s WHERE status = ? AND created_at < ?`, o.table)),
+		StatusDone, before.UnixNano(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("purge: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// pending returns the oldest messages in the Outbox that are not sent.
+func (o *Outbox) pending(ctx context.Context, limit int) ([]Message, error) {
+	rows, err := o.db.QueryContext(ctx, o.dialect.rebind(fmt.Sprintf(`
+SELECT tenant, entity_type, entity_id, event_number, event_name, command_name, payload, metadata
+FROM %s
+WHERE status = ?
+ORDER BY created_at, tenant, entity_type, entity_id, event_number
+LIMIT ?`, o.table)),
+		StatusPending, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("outbox: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var messages []Message
+	for rows.Next() {
+		var (
+			msg      Message
+			metadata string
+		)
+		err = rows.Scan(&msg.Tenant, &msg.EntityType, &msg.EntityID, &msg.EventNumber, &msg.EventName, &msg.CommandName, &msg.Payload, &metadata)
+		if err != nil {
+			return nil, fmt.Errorf("outbox: %w", err)
+		}
+
+		err = json.Unmarshal([]byte(metadata), &msg.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("outbox: %w", err)
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// sent marks a message as sent, keeping it to ignore the event if it is stored again.
+func (o *Outbox) sent(ctx context.Context, msg Message) error {
+	_, err := o.db.ExecContext(ctx, o.dialect.rebind(fmt.Sprintf(`
+UPDATE %s SET status = ? WHERE tenant = ? AND entity_type = ? AND entity_id = ? AND event_number = ?`, o.table)),
+		StatusDone, msg.Tenant, msg.EntityType, msg.EntityID, msg.EventNumber,
+	)
+	if err != nil {
+		return fmt.Errorf("outbox: %w", err)
+	}
+
+	return nil
+}
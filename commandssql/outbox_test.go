@@ -0,0 +1,296 @@
+package commandssql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/commandssql"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestOutbox(t *testing.T) {
+	var (
+		newOutbox = func(t *testing.T) *commandssql.Outbox {
+			var outbox = commandssql.NewOutbox(newDB(t))
+			assert.NoError(t, outbox.Migrate(t.Context()))
+			return outbox
+		}
+		publication = commands.Publication{
+			CommandName: "OpenAccount",
+			EntityType:  "account",
+			EntityID:    "account-1",
+			Position:    2,
+			Events:      []es.Content{AccountOpened{Owner: "first"}, AccountOpened{Owner: "second"}},
+			Metadata:    commands.Metadata{"tenant": "tenant-1"},
+		}
+		event = es.Event{
+			EntityType:  "account",
+			EntityID:    "account-1",
+			EventNumber: 1,
+			Content:     AccountOpened{Owner: "first"},
+		}
+		collect = func(messages *[]commandssql.Message) commandssql.SenderFunc {
+			return func(ctx context.Context, msg commandssql.Message) error {
+				*messages = append(*messages, msg)
+				return nil
+			}
+		}
+	)
+
+	t.Run("relay published events in order", func(t *testing.T) {
+		// arrange
+		var (
+			outbox   = newOutbox(t)
+			messages []commandssql.Message
+			sut      = commandssql.NewRelay(outbox, collect(&messages))
+		)
+		assert.NoError(t, outbox.Publish(t.Context(), publication))
+
+		// act
+		sent, err := sut.Flush(t.Context())
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, 2, sent)
+		if assert.Equal(t, 2, len(messages)) {
+			assert.Equal(t, "account", messages[0].EntityType)
+			assert.Equal(t, "account-1", messages[0].EntityID)
+			assert.Equal(t, int64(1), messages[0].EventNumber)
+			assert.Equal(t, "AccountOpened", messages[0].EventName)
+			assert.Equal(t, "OpenAccount", messages[0].CommandName)
+			assert.Equal(t, `{"Owner":"first"}`, string(messages[0].Payload))
+			assert.Equal(t, "tenant-1", messages[0].Metadata["tenant"])
+			assert.Equal(t, int64(2), messages[1].EventNumber)
+		}
+	})
+
+	t.Run("remove sent messages", func(t *testing.T) {
+		// arrange
+		var (
+			outbox   = newOutbox(t)
+			messages []commandssql.Message
+			sut      = commandssql.NewRelay(outbox, collect(&messages))
+		)
+		assert.NoError(t, outbox.Publish(t.Context(), publication))
+		_, _ = sut.Flush(t.Context())
+
+		// act
+		sent, err := sut.Flush(t.Context())
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, 0, sent)
+		assert.Equal(t, 2, len(messages))
+	})
+
+	t.Run("ignore duplicate publications", func(t *testing.T) {
+		// arrange
+		var (
+			outbox   = newOutbox(t)
+			messages []commandssql.Message
+			sut      = commandssql.NewRelay(outbox, collect(&messages))
+		)
+		assert.NoError(t, outbox.Publish(t.Context(), publication))
+
+		// act
+		err := outbox.Publish(t.Context(), publication)
+
+		// assert
+		assert.NoError(t, err)
+		sent, err := sut.Flush(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, sent)
+	})
+
+	t.Run("retry failed messages", func(t *testing.T) {
+		// arrange
+		var (
+			outbox   = newOutbox(t)
+			sendErr  = errors.New("send-error")
+			messages []commandssql.Message
+			sut      = commandssql.NewRelay(outbox, commandssql.SenderFunc(func(ctx context.Context, msg commandssql.Message) error {
+				messages = append(messages, msg)
+				if len(messages) == 2 {
+					return sendErr
+				}
+				return nil
+			}))
+		)
+		assert.NoError(t, outbox.Publish(t.Context(), publication))
+
+		// act
+		sent, err := sut.Flush(t.Context())
+
+		// assert
+		assert.Truef(t, errors.Is(err, sendErr), "expected send error, got %v", err)
+		assert.Equal(t, 1, sent)
+		sent, err = sut.Flush(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		if assert.Equal(t, 3, len(messages)) {
+			assert.Equal(t, int64(2), messages[2].EventNumber)
+		}
+	})
+
+	t.Run("relay events from the event log", func(t *testing.T) {
+		// arrange
+		var (
+			outbox   = newOutbox(t)
+			messages []commandssql.Message
+			sut      = commandssql.NewRelay(outbox, collect(&messages))
+		)
+
+		// act
+		err := outbox.Handle(t.Context(), event)
+
+		// assert
+		assert.NoError(t, err)
+		sent, err := sut.Flush(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		if assert.Equal(t, 1, len(messages)) {
+			assert.Equal(t, "account-1", messages[0].EntityID)
+			assert.Equal(t, int64(1), messages[0].EventNumber)
+			assert.Equal(t, "AccountOpened", messages[0].EventName)
+			assert.Equal(t, "", messages[0].CommandName)
+			assert.Equal(t, `{"Owner":"first"}`, string(messages[0].Payload))
+		}
+	})
+
+	t.Run("add publication to events from the event log", func(t *testing.T) {
+		// arrange
+		var (
+			outbox   = newOutbox(t)
+			messages []commandssql.Message
+			sut      = commandssql.NewRelay(outbox, collect(&messages))
+		)
+		assert.NoError(t, outbox.Handle(t.Context(), event))
+
+		// act
+		err := outbox.Publish(t.Context(), publication)
+
+		// assert
+		assert.NoError(t, err)
+		sent, err := sut.Flush(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, sent)
+		if assert.Equal(t, 2, len(messages)) {
+			assert.Equal(t, "OpenAccount", messages[0].CommandName)
+			assert.Equal(t, "tenant-1", messages[0].Metadata["tenant"])
+		}
+	})
+
+	t.Run("ignore sent events from the event log", func(t *testing.T) {
+		// arrange
+		var (
+			outbox   = newOutbox(t)
+			messages []commandssql.Message
+			sut      = commandssql.NewRelay(outbox, collect(&messages))
+		)
+		assert.NoError(t, outbox.Publish(t.Context(), publication))
+		_, _ = sut.Flush(t.Context())
+
+		// act
+		err := outbox.Handle(t.Context(), event)
+
+		// assert
+		assert.NoError(t, err)
+		sent, err := sut.Flush(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, sent)
+	})
+
+	t.Run("purge sent messages", func(t *testing.T) {
+		// arrange
+		var (
+			outbox   = newOutbox(t)
+			messages []commandssql.Message
+			sut      = commandssql.NewRelay(outbox, collect(&messages))
+		)
+		assert.NoError(t, outbox.Publish(t.Context(), publication))
+		_, _ = sut.Flush(t.Context())
+		assert.NoError(t, outbox.Handle(t.Context(), es.Event{EntityType: "account", EntityID: "account-2", EventNumber: 1, Content: AccountOpened{}}))
+
+		// act
+		purged, err := outbox.Purge(t.Context(), time.Now().Add(time.Hour))
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), purged)
+		sent, err := sut.Flush(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, sent)
+	})
+
+	t.Run("relay events written by commands", func(t *testing.T) {
+		// arrange
+		var (
+			outbox     = newOutbox(t)
+			received   = make(chan commandssql.Message)
+			dispatcher = newDispatcher(t, func(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error) {
+				return []es.Content{AccountOpened{Owner: cmd.Owner}}, nil
+			}, commands.WithPublisher(outbox))
+			sut = commandssql.NewRelay(outbox, commandssql.SenderFunc(func(ctx context.Context, msg commandssql.Message) error {
+				received <- msg
+				return nil
+			}), commandssql.WithRelayPollInterval(time.Millisecond))
+			ctx, cancel = context.WithCancel(t.Context())
+			done        = make(chan error)
+		)
+
+		go func() {
+			done <- sut.Run(ctx)
+		}()
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", OpenAccount{Owner: "owner-1"})
+
+		// assert
+		assert.NoError(t, err)
+		msg := <-received
+		assert.Equal(t, "AccountOpened", msg.EventName)
+		assert.Equal(t, "account-1", msg.EntityID)
+		assert.Equal(t, int64(1), msg.EventNumber)
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("relay events of tenants with the same entity", func(t *testing.T) {
+		// arrange
+		var (
+			outbox     = newOutbox(t)
+			messages   []commandssql.Message
+			stores     = map[string]commands.Store{"tenant-1": newStore(t), "tenant-2": newStore(t)}
+			dispatcher = newDispatcher(t, func(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error) {
+				return []es.Content{AccountOpened{Owner: cmd.Owner}}, nil
+			}, commands.WithPublisher(outbox), commands.WithTenants(commands.TenantStores(func(tenant string) (commands.Store, error) {
+				return stores[tenant], nil
+			})))
+			sut = commandssql.NewRelay(outbox, collect(&messages))
+		)
+
+		// act
+		for _, tenant := range []string{"tenant-1", "tenant-2"} {
+			ctx := commands.ContextWithTenant(t.Context(), tenant)
+			assert.NoError(t, dispatcher.Dispatch(ctx, "account-1", OpenAccount{Owner: tenant}))
+			assert.NoError(t, outbox.Tenant(tenant).Handle(ctx, es.Event{EntityType: "account", EntityID: "account-1", EventNumber: 1, Content: AccountOpened{Owner: tenant}}))
+		}
+
+		// assert
+		sent, err := sut.Flush(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, sent)
+		if assert.Equal(t, 2, len(messages)) {
+			assert.Equal(t, "tenant-1", messages[0].Tenant)
+			assert.Equal(t, "account", messages[0].EntityType)
+			assert.Equal(t, `{"Owner":"tenant-1"}`, string(messages[0].Payload))
+			assert.Equal(t, "tenant-2", messages[1].Tenant)
+			assert.Equal(t, `{"Owner":"tenant-2"}`, string(messages[1].Payload))
+		}
+	})
+
+}
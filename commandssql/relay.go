@@ -0,0 +1,113 @@
+package commandssql
+
+import (
+	"context"
+	"time"
+)
+
+// Sender sends a Message from the Outbox to other services.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type SenderFunc func(ctx context.Context, msg Message) error
+
+func (fn SenderFunc) Send(ctx context.Context, msg Message) error {
+	return fn(ctx, msg)
+}
+
+type RelayOption func(r *Relay)
+
+// WithRelayBatchSize sets how many messages are read from the Outbox at a time. Defaults to 100.
+func WithRelayBatchSize(size int) RelayOption {
+	return func(r *Relay) {
+		r.batchSize = size
+	}
+}
+
+// WithRelayPollInterval sets how often the Outbox is read when it is empty, or sending failed. Defaults to 1 second.
+func WithRelayPollInterval(interval time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.pollInterval = interval
+	}
+}
+
+// WithRelayOnError is called when reading the Outbox or sending a Message fails.
+func WithRelayOnError(fn func(ctx context.Context, err error)) RelayOption {
+	return func(r *Relay) {
+		r.onError = fn
+	}
+}
+
+// NewRelay creates a Relay that sends the messages in the Outbox with the Sender.
+func NewRelay(outbox *Outbox, sender Sender, opts ...RelayOption) *Relay {
+	var r = &Relay{
+		outbox:       outbox,
+		sender:       sender,
+		batchSize:    100,
+		pollInterval: time.Second,
+		onError:      func(ctx context.Context, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Relay sends messages from an Outbox in the order they were published, at least once.
+// A Message is marked as sent in the Outbox once it is sent, and sending is retried until it
+// succeeds, so a failing Message holds back the ones after it.
+type Relay struct {
+	outbox       *Outbox
+	sender       Sender
+	batchSize    int
+	pollInterval time.Duration
+	onError      func(ctx context.Context, err error)
+}
+
+// Run sends messages until the context is done.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		sent, err := r.Flush(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			r.onError(ctx, err)
+		}
+
+		if err == nil && sent == r.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// Flush sends a single batch of messages, returning how many were sent.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	messages, err := r.outbox.pending(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, msg := range messages {
+		err = r.sender.Send(ctx, msg)
+		if err != nil {
+			return i, err
+		}
+
+		err = r.outbox.sent(ctx, msg)
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(messages), nil
+}
@@ -0,0 +1,48 @@
+package commandssql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	commands "github.com/kyuff/es-commands"
+)
+
+var _ commands.Scheduler = (*Scheduler)(nil)
+
+// NewScheduler creates a commands.Scheduler that keeps the envelopes in a table until they are due.
+// It accepts the same options as NewSource, with the table defaulting to commands_schedule.
+func NewScheduler(db *sql.DB, opts ...SourceOption) *Scheduler {
+	return &Scheduler{
+		Source: NewSource(db, append([]SourceOption{WithSourceTable("commands_schedule")}, opts...)...),
+	}
+}
+
+type Scheduler struct {
+	*Source
+}
+
+func (s *Scheduler) Schedule(ctx context.Context, at time.Time, env commands.Envelope) error {
+	return s.insert(ctx, env, at, "")
+}
+
+func (s *Scheduler) Cancel(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, s.dialect.rebind(fmt.Sprintf(`
+DELETE FROM %s WHERE id = ? AND status = ?`, s.table)),
+		id, StatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("cancel %s: %w", id, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("cancel %s: %w", id, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("cancel %s: %w", id, commands.ErrNotScheduled)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package commandssql_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/commandssql"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestScheduler(t *testing.T) {
+	var (
+		newScheduler = func(t *testing.T, db *sql.DB, now func() time.Time) *commandssql.Scheduler {
+			var scheduler = commandssql.NewScheduler(db,
+				commandssql.WithPollInterval(time.Millisecond),
+				commandssql.WithSourceClock(now),
+			)
+			assert.NoError(t, scheduler.Migrate(t.Context()))
+			return scheduler
+		}
+		envelope = commands.Envelope{
+			ID:          "envelope-1",
+			CommandName: "OpenAccount",
+			EntityID:    "account-1",
+			Payload:     []byte(`{"Owner": "owner-1"}`),
+		}
+	)
+
+	t.Run("deliver envelope when due", func(t *testing.T) {
+		// arrange
+		var (
+			clock = newClock()
+			sut   = newScheduler(t, newDB(t), clock.Now)
+		)
+
+		// act
+		err := sut.Schedule(t.Context(), clock.Now().Add(time.Hour), envelope)
+
+		// assert
+		assert.NoError(t, err)
+		assertEmpty(t, sut.Source)
+		clock.Advance(time.Hour)
+		got, err := sut.Receive(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, envelope.ID, got.ID)
+	})
+
+	t.Run("keep schedule across restarts", func(t *testing.T) {
+		// arrange
+		var (
+			clock = newClock()
+			db    = newDB(t)
+		)
+		assert.NoError(t, newScheduler(t, db, clock.Now).Schedule(t.Context(), clock.Now().Add(time.Hour), envelope))
+
+		// act
+		clock.Advance(time.Hour)
+		got, err := newScheduler(t, db, clock.Now).Receive(t.Context())
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, envelope.ID, got.ID)
+	})
+
+	t.Run("cancel scheduled envelope", func(t *testing.T) {
+		// arrange
+		var (
+			clock = newClock()
+			sut   = newScheduler(t, newDB(t), clock.Now)
+		)
+		assert.NoError(t, sut.Schedule(t.Context(), clock.Now().Add(time.Hour), envelope))
+
+		// act
+		err := sut.Cancel(t.Context(), envelope.ID)
+
+		// assert
+		assert.NoError(t, err)
+		clock.Advance(time.Hour)
+		assertEmpty(t, sut.Source)
+	})
+
+	t.Run("fail cancelling delivered envelope", func(t *testing.T) {
+		// arrange
+		var (
+			clock = newClock()
+			sut   = newScheduler(t, newDB(t), clock.Now)
+		)
+		assert.NoError(t, sut.Schedule(t.Context(), clock.Now(), envelope))
+		_, err := sut.Receive(t.Context())
+		assert.NoError(t, err)
+
+		// act
+		err = sut.Cancel(t.Context(), envelope.ID)
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNotScheduled), "expected ErrNotScheduled, got %v", err)
+	})
+
+	t.Run("dispatch due commands with consumer", func(t *testing.T) {
+		// arrange
+		var (
+			now         atomic.Int64
+			clock       = func() time.Time { return time.Unix(0, now.Load()) }
+			scheduler   = newScheduler(t, newDB(t), clock)
+			received    = make(chan OpenAccount)
+			ctx, cancel = context.WithCancel(t.Context())
+			done        = make(chan error)
+			dispatcher  = newDispatcher(t, func(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error) {
+				received <- cmd
+				return []es.Content{AccountOpened{Owner: cmd.Owner}}, nil
+			}, commands.WithScheduler(scheduler))
+		)
+		now.Store(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+
+		_, err := dispatcher.Schedule(t.Context(), clock().Add(time.Minute), "account-1", OpenAccount{Owner: "owner-1"})
+		assert.NoError(t, err)
+
+		go func() {
+			done <- commands.NewConsumer(dispatcher, scheduler).Run(ctx)
+		}()
+
+		// act
+		now.Add(int64(time.Minute))
+
+		// assert
+		assert.Equal(t, "owner-1", (<-received).Owner)
+		cancel()
+		assert.NoError(t, <-done)
+	})
+}
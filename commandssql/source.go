@@ -0,0 +1,309 @@
+package commandssql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	commands "github.com/kyuff/es-commands"
+)
+
+// Status of an Envelope in a table.
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusProcessing Status = "processing"
+	StatusDone       Status = "done"
+	StatusDead       Status = "dead"
+)
+
+type SourceOption func(s *Source)
+
+// WithSourceTable sets the table the envelopes are stored in. Defaults to commands_queue.
+func WithSourceTable(table string) SourceOption {
+	return func(s *Source) {
+		s.table = table
+	}
+}
+
+func WithSourceDialect(dialect Dialect) SourceOption {
+	return func(s *Source) {
+		s.dialect = dialect
+	}
+}
+
+// WithPollInterval sets how often the table is queried when it is empty. Defaults to 1 second.
+func WithPollInterval(interval time.Duration) SourceOption {
+	return func(s *Source) {
+		s.pollInterval = interval
+	}
+}
+
+// WithLockTimeout sets how long a received envelope is hidden from other consumers.
+// Envelopes not settled within the timeout are delivered again. Defaults to 1 minute.
+func WithLockTimeout(timeout time.Duration) SourceOption {
+	return func(s *Source) {
+		s.lockTimeout = timeout
+	}
+}
+
+// WithRetryDelay sets how long a nacked envelope waits before it is delivered again. Defaults to 1 second.
+func WithRetryDelay(delay time.Duration) SourceOption {
+	return func(s *Source) {
+		s.retryDelay = delay
+	}
+}
+
+func WithSourceClock(now func() time.Time) SourceOption {
+	return func(s *Source) {
+		s.now = now
+	}
+}
+
+// NewSource creates a commands.Source that polls a table for envelopes.
+func NewSource(db *sql.DB, opts ...SourceOption) *Source {
+	var s = &Source{
+		db:           db,
+		table:        "commands_queue",
+		dialect:      SQLite,
+		pollInterval: time.Second,
+		lockTimeout:  time.Minute,
+		retryDelay:   time.Second,
+		now:          time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+type Source struct {
+	db           *sql.DB
+	table        string
+	dialect      Dialect
+	pollInterval time.Duration
+	lockTimeout  time.Duration
+	retryDelay   time.Duration
+	now          func() time.Time
+}
+
+// Migrate creates the table if it does not exist.
+func (s *Source) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+	id           VARCHAR(64) PRIMARY KEY,
+	command_name VARCHAR(255) NOT NULL,
+	entity_id    VARCHAR(255) NOT NULL,
+	payload      %s NOT NULL,
+	metadata     TEXT NOT NULL,
+	attempts     INTEGER NOT NULL,
+	status       VARCHAR(16) NOT NULL,
+	queued_at    BIGINT NOT NULL,
+	available_at BIGINT NOT NULL,
+	last_error   TEXT NOT NULL
+)`, s.table, s.dialect.Blob))
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", s.table, err)
+	}
+
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf(`
+CREATE INDEX IF NOT EXISTS %s_available ON %s (status, available_at)`, s.table, s.table))
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", s.table, err)
+	}
+
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf(`
+CREATE INDEX IF NOT EXISTS %s_entity ON %s (entity_id, queued_at)`, s.table, s.table))
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", s.table, err)
+	}
+
+	return nil
+}
+
+// Send an Envelope to the Source.
+func (s *Source) Send(ctx context.Context, env commands.Envelope) error {
+	return s.insert(ctx, env, s.now(), "")
+}
+
+func (s *Source) Receive(ctx context.Context) (commands.Envelope, error) {
+	for {
+		env, ok, err := s.claim(ctx)
+		if err != nil {
+			return commands.Envelope{}, err
+		}
+		if ok {
+			return env, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return commands.Envelope{}, ctx.Err()
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+// claim the next available envelope. An envelope is available when it is pending, or its lock expired,
+// and no envelope for the same entity was queued before it.
+func (s *Source) claim(ctx context.Context) (commands.Envelope, bool, error) {
+	var (
+		now      = s.now().UnixNano()
+		env      commands.Envelope
+		metadata string
+	)
+
+	err := s.db.QueryRowContext(ctx, s.dialect.rebind(fmt.Sprintf(`
+SELECT id, command_name, entity_id, payload, metadata, attempts
+FROM %[1]s q
+WHERE status IN (?, ?) AND available_at <= ? AND NOT EXISTS (
+	SELECT 1 FROM %[1]s b
+	WHERE b.entity_id = q.entity_id AND b.status IN (?, ?)
+	AND (b.queued_at < q.queued_at OR (b.queued_at = q.queued_at AND b.id < q.id))
+)
+ORDER BY available_at, id
+LIMIT 1`, s.table)),
+		StatusPending, StatusProcessing, now, StatusPending, StatusProcessing,
+	).Scan(&env.ID, &env.CommandName, &env.EntityID, &env.Payload, &metadata, &env.Attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return commands.Envelope{}, false, nil
+	}
+	if err != nil {
+		return commands.Envelope{}, false, fmt.Errorf("receive: %w", err)
+	}
+
+	res, err := s.db.ExecContext(ctx, s.dialect.rebind(fmt.Sprintf(`
+UPDATE %s SET status = ?, available_at = ?
+WHERE id = ? AND status IN (?, ?) AND available_at <= ?`, s.table)),
+		StatusProcessing, now+s.lockTimeout.Nanoseconds(),
+		env.ID, StatusPending, StatusProcessing, now,
+	)
+	if err != nil {
+		return commands.Envelope{}, false, fmt.Errorf("receive %s: %w", env.ID, err)
+	}
+
+	claimed, err := res.RowsAffected()
+	if err != nil {
+		return commands.Envelope{}, false, fmt.Errorf("receive %s: %w", env.ID, err)
+	}
+	if claimed == 0 {
+		// claimed by another consumer
+		return s.claim(ctx)
+	}
+
+	err = json.Unmarshal([]byte(metadata), &env.Metadata)
+	if err != nil {
+		return commands.Envelope{}, false, fmt.Errorf("receive %s: %w", env.ID, err)
+	}
+
+	return env, true, nil
+}
+
+func (s *Source) Ack(ctx context.Context, env commands.Envelope) error {
+	_, err := s.db.ExecContext(ctx, s.dialect.rebind(fmt.Sprintf(`DELETE FROM %s WHERE id = ?`, s.table)), env.ID)
+	if err != nil {
+		return fmt.Errorf("ack %s: %w", env.ID, err)
+	}
+
+	return nil
+}
+
+// Nack delivers the Envelope again after the retry delay, before any later envelopes for the entity.
+func (s *Source) Nack(ctx context.Context, env commands.Envelope, cause error) error {
+	return s.update(ctx, env, StatusPending, s.now().Add(s.retryDelay), cause)
+}
+
+func (s *Source) DeadLetter(ctx context.Context, env commands.Envelope, cause error) error {
+	return s.update(ctx, env, StatusDead, s.now(), cause)
+}
+
+// DeadLetters returns the envelopes that were given up on.
+func (s *Source) DeadLetters(ctx context.Context) ([]commands.Envelope, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(fmt.Sprintf(`
+SELECT id, command_name, entity_id, payload, metadata, attempts
+FROM %s
+WHERE status = ?
+ORDER BY available_at, id`, s.table)),
+		StatusDead,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("dead letters: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var envelopes []commands.Envelope
+	for rows.Next() {
+		var (
+			env      commands.Envelope
+			metadata string
+		)
+		err = rows.Scan(&env.ID, &env.CommandName, &env.EntityID, &env.Payload, &metadata, &env.Attempts)
+		if err != nil {
+			return nil, fmt.Errorf("dead letters: %w", err)
+		}
+
+		err = json.Unmarshal([]byte(metadata), &env.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("dead letters: %w", err)
+		}
+
+		envelopes = append(envelopes, env)
+	}
+
+	return envelopes, rows.Err()
+}
+
+func (s *Source) update(ctx context.Context, env commands.Envelope, status Status, availableAt time.Time, cause error) error {
+	var lastError string
+	if cause != nil {
+		lastError = cause.Error()
+	}
+
+	_, err := s.db.ExecContext(ctx, s.dialect.rebind(fmt.Sprintf(`
+UPDATE %s SET status = ?, attempts = attempts + 1, available_at = ?, last_error = ?
+WHERE id = ?`, s.table)),
+		status, availableAt.UnixNano(), lastError, env.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", status, env.ID, err)
+	}
+
+	return nil
+}
+
+func marshalMetadata(metadata commands.Metadata) (string, error) {
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// insert the Envelope as pending until the given time. The onConflict clause is appended to the statement.
+func (s *Source) insert(ctx context.Context, env commands.Envelope, availableAt time.Time, onConflict string) error {
+	metadata, err := marshalMetadata(env.Metadata)
+	if err != nil {
+		return fmt.Errorf("send %s: %w", env.ID, err)
+	}
+
+	_, err = s.db.ExecContext(ctx, s.dialect.rebind(fmt.Sprintf(`
+INSERT INTO %s (id, command_name, entity_id, payload, metadata, attempts, status, queued_at, available_at, last_error)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, '')
+%s`, s.table, onConflict)),
+		env.ID, env.CommandName, env.EntityID, env.Payload, metadata, env.Attempts, StatusPending, availableAt.UnixNano(), availableAt.UnixNano(),
+	)
+	if err != nil {
+		return fmt.Errorf("send %s: %w", env.ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,283 @@
+package commandssql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/commandssql"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestSource(t *testing.T) {
+	var (
+		newSource = func(t *testing.T, opts ...commandssql.SourceOption) *commandssql.Source {
+			var source = commandssql.NewSource(newDB(t), append([]commandssql.SourceOption{
+				commandssql.WithPollInterval(time.Millisecond),
+			}, opts...)...)
+			assert.NoError(t, source.Migrate(t.Context()))
+			return source
+		}
+		envelope = commands.Envelope{
+			ID:          "envelope-1",
+			CommandName: "OpenAccount",
+			EntityID:    "account-1",
+			Payload:     []byte(`{"Owner": "owner-1"}`),
+			Metadata:    commands.Metadata{"tenant": "tenant-1"},
+		}
+	)
+
+	t.Run("migrate twice", func(t *testing.T) {
+		// arrange
+		var sut = newSource(t)
+
+		// act
+		err := sut.Migrate(t.Context())
+
+		// assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("receive sent envelope", func(t *testing.T) {
+		// arrange
+		var sut = newSource(t)
+		assert.NoError(t, sut.Send(t.Context(), envelope))
+
+		// act
+		got, err := sut.Receive(t.Context())
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, envelope.ID, got.ID)
+		assert.Equal(t, envelope.CommandName, got.CommandName)
+		assert.Equal(t, envelope.EntityID, got.EntityID)
+		assert.Equal(t, string(envelope.Payload), string(got.Payload))
+		assert.Equal(t, "tenant-1", got.Metadata["tenant"])
+		assert.Equal(t, 0, got.Attempts)
+	})
+
+	t.Run("fail sending duplicate envelope", func(t *testing.T) {
+		// arrange
+		var sut = newSource(t)
+		assert.NoError(t, sut.Send(t.Context(), envelope))
+
+		// act
+		err := sut.Send(t.Context(), envelope)
+
+		// assert
+		assert.Error(t, err)
+	})
+
+	t.Run("wait for envelopes until context is done", func(t *testing.T) {
+		// arrange
+		var (
+			sut         = newSource(t)
+			ctx, cancel = context.WithTimeout(t.Context(), 5*time.Millisecond)
+		)
+		defer cancel()
+
+		// act
+		_, err := sut.Receive(ctx)
+
+		// assert
+		assert.Truef(t, errors.Is(err, context.DeadlineExceeded), "expected context.DeadlineExceeded, got %v", err)
+	})
+
+	t.Run("remove acked envelope", func(t *testing.T) {
+		// arrange
+		var (
+			clock = newClock()
+			sut   = newSource(t, commandssql.WithSourceClock(clock.Now), commandssql.WithLockTimeout(time.Second))
+		)
+		assert.NoError(t, sut.Send(t.Context(), envelope))
+		got, _ := sut.Receive(t.Context())
+
+		// act
+		err := sut.Ack(t.Context(), got)
+
+		// assert
+		assert.NoError(t, err)
+		clock.Advance(time.Hour)
+		assertEmpty(t, sut)
+	})
+
+	t.Run("redeliver nacked envelope after delay", func(t *testing.T) {
+		// arrange
+		var (
+			clock = newClock()
+			sut   = newSource(t, commandssql.WithSourceClock(clock.Now), commandssql.WithRetryDelay(time.Second))
+		)
+		assert.NoError(t, sut.Send(t.Context(), envelope))
+		first, _ := sut.Receive(t.Context())
+
+		// act
+		err := sut.Nack(t.Context(), first, errors.New("executor-error"))
+
+		// assert
+		assert.NoError(t, err)
+		assertEmpty(t, sut)
+		clock.Advance(time.Second)
+		got, err := sut.Receive(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, envelope.ID, got.ID)
+		assert.Equal(t, 1, got.Attempts)
+	})
+
+	t.Run("hold later envelopes for entity until nacked envelope is redelivered", func(t *testing.T) {
+		// arrange
+		var (
+			clock = newClock()
+			sut   = newSource(t, commandssql.WithSourceClock(clock.Now), commandssql.WithRetryDelay(time.Second))
+			later = envelope
+			other = envelope
+		)
+		later.ID = "envelope-2"
+		other.ID, other.EntityID = "envelope-3", "account-2"
+		assert.NoError(t, sut.Send(t.Context(), envelope))
+		clock.Advance(time.Millisecond)
+		assert.NoError(t, sut.Send(t.Context(), later))
+		assert.NoError(t, sut.Send(t.Context(), other))
+		first, _ := sut.Receive(t.Context())
+
+		// act
+		err := sut.Nack(t.Context(), first, errors.New("executor-error"))
+
+		// assert
+		assert.NoError(t, err)
+		got, err := sut.Receive(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, other.ID, got.ID)
+		assertEmpty(t, sut)
+		clock.Advance(time.Second)
+		got, err = sut.Receive(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, envelope.ID, got.ID)
+		assertEmpty(t, sut)
+		assert.NoError(t, sut.Ack(t.Context(), got))
+		got, err = sut.Receive(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, later.ID, got.ID)
+	})
+
+	t.Run("redeliver envelope after lock timeout", func(t *testing.T) {
+		// arrange
+		var (
+			clock = newClock()
+			sut   = newSource(t, commandssql.WithSourceClock(clock.Now), commandssql.WithLockTimeout(time.Minute))
+		)
+		assert.NoError(t, sut.Send(t.Context(), envelope))
+		_, _ = sut.Receive(t.Context())
+		assertEmpty(t, sut)
+
+		// act
+		clock.Advance(time.Minute)
+		got, err := sut.Receive(t.Context())
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, envelope.ID, got.ID)
+	})
+
+	t.Run("keep dead letters", func(t *testing.T) {
+		// arrange
+		var (
+			clock = newClock()
+			sut   = newSource(t, commandssql.WithSourceClock(clock.Now))
+		)
+		assert.NoError(t, sut.Send(t.Context(), envelope))
+		first, _ := sut.Receive(t.Context())
+
+		// act
+		err := sut.DeadLetter(t.Context(), first, errors.New("executor-error"))
+
+		// assert
+		assert.NoError(t, err)
+		clock.Advance(time.Hour)
+		assertEmpty(t, sut)
+		got, err := sut.DeadLetters(t.Context())
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, envelope.ID, got[0].ID)
+			assert.Equal(t, 1, got[0].Attempts)
+		}
+	})
+
+	t.Run("use table name", func(t *testing.T) {
+		// arrange
+		var (
+			db  = newDB(t)
+			sut = commandssql.NewSource(db, commandssql.WithSourceTable("my_commands"))
+		)
+		assert.NoError(t, sut.Migrate(t.Context()))
+
+		// act
+		err := sut.Send(t.Context(), envelope)
+
+		// assert
+		assert.NoError(t, err)
+		var count int
+		assert.NoError(t, db.QueryRowContext(t.Context(), `SELECT COUNT(*) FROM my_commands`).Scan(&count))
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("index envelopes by availability and entity", func(t *testing.T) {
+		// arrange
+		var (
+			db  = newDB(t)
+			sut = commandssql.NewSource(db)
+		)
+
+		// act
+		err := sut.Migrate(t.Context())
+
+		// assert
+		assert.NoError(t, err)
+		var count int
+		assert.NoError(t, db.QueryRowContext(t.Context(), `
+SELECT COUNT(*) FROM sqlite_master
+WHERE type = 'index' AND name IN ('commands_queue_available', 'commands_queue_entity')`).Scan(&count))
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("dispatch with consumer", func(t *testing.T) {
+		// arrange
+		var (
+			source     = newSource(t)
+			received   = make(chan OpenAccount)
+			dispatcher = newDispatcher(t, func(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error) {
+				received <- cmd
+				return []es.Content{AccountOpened{Owner: cmd.Owner}}, nil
+			})
+			consumer    = commands.NewConsumer(dispatcher, source)
+			ctx, cancel = context.WithCancel(t.Context())
+			done        = make(chan error)
+		)
+
+		env, err := dispatcher.Envelope(t.Context(), "account-1", OpenAccount{Owner: "owner-1"})
+		assert.NoError(t, err)
+
+		go func() {
+			done <- consumer.Run(ctx)
+		}()
+
+		// act
+		assert.NoError(t, source.Send(t.Context(), env))
+
+		// assert
+		assert.Equal(t, "owner-1", (<-received).Owner)
+		cancel()
+		assert.NoError(t, <-done)
+	})
+}
+
+func assertEmpty(t *testing.T, source *commandssql.Source) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Millisecond)
+	defer cancel()
+
+	got, err := source.Receive(ctx)
+	assert.Truef(t, errors.Is(err, context.DeadlineExceeded), "expected no envelopes, got %v", got.ID)
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+)
+
+// Source delivers envelopes to a Consumer. Each received Envelope is settled by exactly one
+// of Ack, Nack or DeadLetter.
+type Source interface {
+	// Receive blocks until an Envelope is available or the context is done.
+	Receive(ctx context.Context) (Envelope, error)
+	// Ack removes a successfully dispatched Envelope.
+	Ack(ctx context.Context, env Envelope) error
+	// Nack makes the Envelope available again with one more attempt. Later envelopes for
+	// the same entity are not delivered before it.
+	Nack(ctx context.Context, env Envelope, cause error) error
+	// DeadLetter removes the Envelope from delivery, keeping it for inspection.
+	DeadLetter(ctx context.Context, env Envelope, cause error) error
+}
+
+type ConsumerOption func(c *Consumer)
+
+// ConsumerMaxAttempts sets how many times a command is tried before it is dead-lettered. Defaults to 5.
+func ConsumerMaxAttempts(attempts int) ConsumerOption {
+	return func(c *Consumer) {
+		c.maxAttempts = attempts
+	}
+}
+
+// ConsumerPartitions sets how many commands are dispatched concurrently. Envelopes are
+// partitioned by entity ID, so commands for the same entity are dispatched in order. Defaults to 1.
+func ConsumerPartitions(partitions int) ConsumerOption {
+	return func(c *Consumer) {
+		c.partitions = partitions
+	}
+}
+
+// ConsumerOnError is called when a command fails, or when settling an Envelope with the Source fails.
+func ConsumerOnError(fn func(ctx context.Context, env Envelope, err error)) ConsumerOption {
+	return func(c *Consumer) {
+		c.onError = fn
+	}
+}
+
+// NewConsumer creates a Consumer that dispatches the envelopes from the Source.
+func NewConsumer(dispatcher *Dispatcher, source Source, opts ...ConsumerOption) *Consumer {
+	var c = &Consumer{
+		dispatcher:  dispatcher,
+		source:      source,
+		maxAttempts: 5,
+		partitions:  1,
+		onError:     func(ctx context.Context, env Envelope, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type Consumer struct {
+	dispatcher  *Dispatcher
+	source      Source
+	maxAttempts int
+	partitions  int
+	onError     func(ctx context.Context, env Envelope, err error)
+}
+
+// Run receives and dispatches envelopes until the context is done. Commands being
+// dispatched or received when the context is done are completed before Run returns.
+func (c *Consumer) Run(ctx context.Context) error {
+	var (
+		wg         sync.WaitGroup
+		partitions = make([]chan Envelope, max(c.partitions, 1))
+	)
+
+	for i := range partitions {
+		partitions[i] = make(chan Envelope)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for env := range partitions[i] {
+				c.handle(context.WithoutCancel(ctx), env)
+			}
+		}()
+	}
+
+	defer func() {
+		for _, partition := range partitions {
+			close(partition)
+		}
+		wg.Wait()
+	}()
+
+	for {
+		env, err := c.source.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		partitions[partition(env.EntityID, len(partitions))] <- env
+	}
+}
+
+func (c *Consumer) handle(ctx context.Context, env Envelope) {
+	err := c.dispatcher.DispatchEnvelope(ctx, env)
+	switch {
+	case err == nil:
+		c.settle(ctx, env, c.source.Ack(ctx, env))
+		return
+	case errors.Is(err, ErrNotRegistered) || errors.Is(err, ErrInvalidCommand) || env.Attempts+1 >= c.maxAttempts:
+		c.onError(ctx, env, err)
+		c.settle(ctx, env, c.source.DeadLetter(ctx, env, err))
+	default:
+		c.onError(ctx, env, err)
+		c.settle(ctx, env, c.source.Nack(ctx, env, err))
+	}
+}
+
+func (c *Consumer) settle(ctx context.Context, env Envelope, err error) {
+	if err != nil {
+		c.onError(ctx, env, err)
+	}
+}
+
+func partition(entityID string, partitions int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(entityID))
+	return int(h.Sum32() % uint32(partitions))
+}
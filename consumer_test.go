@@ -0,0 +1,317 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestConsumer(t *testing.T) {
+	var (
+		newStore = func() *StoreMock {
+			return &StoreMock{
+				OpenFunc: func(ctx context.Context, entityType string, entityID string) es.Stream {
+					return &StreamMock{
+						ProjectFunc: func(handler es.Handler) error {
+							return nil
+						},
+						WriteFunc: func(events ...es.Content) error {
+							return nil
+						},
+						CloseFunc: func() error {
+							return nil
+						},
+					}
+				},
+			}
+		}
+		run = func(t *testing.T, consumer *commands.Consumer) (stop func()) {
+			var (
+				ctx, cancel = context.WithCancel(t.Context())
+				done        = make(chan error)
+			)
+			go func() {
+				done <- consumer.Run(ctx)
+			}()
+			return func() {
+				cancel()
+				assert.NoError(t, <-done)
+			}
+		}
+		envelope = func(t *testing.T, dispatcher *commands.Dispatcher, entityID string, cmd commands.Command) commands.Envelope {
+			env, err := dispatcher.Envelope(t.Context(), entityID, cmd)
+			assert.NoError(t, err)
+			return env
+		}
+	)
+
+	t.Run("dispatch envelopes from source", func(t *testing.T) {
+		// arrange
+		var (
+			source     = commands.NewChannelSource()
+			dispatcher = commands.NewDispatcher(newStore())
+			sut        = commands.NewConsumer(dispatcher, source)
+			received   = make(chan string)
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			received <- cmd.Value + ":" + commands.MetadataFromContext(ctx)["tenant"]
+			return nil, nil
+		})
+
+		stop := run(t, sut)
+		defer stop()
+
+		env := envelope(t, dispatcher, "account-1", TestCommand{Value: "value"})
+		env.Metadata = commands.Metadata{"tenant": "tenant-1"}
+
+		// act
+		assert.NoError(t, source.Send(t.Context(), env))
+
+		// assert
+		assert.Equal(t, "value:tenant-1", <-received)
+	})
+
+	t.Run("retry failed commands", func(t *testing.T) {
+		// arrange
+		var (
+			source     = commands.NewChannelSource(commands.ChannelSourceRetryDelay(time.Millisecond))
+			dispatcher = commands.NewDispatcher(newStore())
+			sut        = commands.NewConsumer(dispatcher, source, commands.ConsumerMaxAttempts(3))
+			attempts   = make(chan int, 3)
+			calls      = 0
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			calls++
+			attempts <- calls
+			if calls < 2 {
+				return nil, errors.New("executor-error")
+			}
+			return nil, nil
+		})
+
+		stop := run(t, sut)
+		defer stop()
+
+		// act
+		assert.NoError(t, source.Send(t.Context(), envelope(t, dispatcher, "account-1", TestCommand{})))
+
+		// assert
+		assert.Equal(t, 1, <-attempts)
+		assert.Equal(t, 2, <-attempts)
+	})
+
+	t.Run("dead letter after max attempts", func(t *testing.T) {
+		// arrange
+		var (
+			source     = commands.NewChannelSource(commands.ChannelSourceRetryDelay(time.Millisecond))
+			dispatcher = commands.NewDispatcher(newStore())
+			errs       = make(chan error, 10)
+			sut        = commands.NewConsumer(dispatcher, source,
+				commands.ConsumerMaxAttempts(2),
+				commands.ConsumerOnError(func(ctx context.Context, env commands.Envelope, err error) {
+					errs <- err
+				}),
+			)
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, errors.New("executor-error")
+		})
+
+		stop := run(t, sut)
+
+		// act
+		assert.NoError(t, source.Send(t.Context(), envelope(t, dispatcher, "account-1", TestCommand{})))
+		<-errs
+		<-errs
+		stop()
+
+		// assert
+		got := source.DeadLetters()
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, 2, got[0].Attempts)
+		}
+	})
+
+	t.Run("dead letter undecodable envelopes", func(t *testing.T) {
+		// arrange
+		var (
+			source     = commands.NewChannelSource()
+			dispatcher = commands.NewDispatcher(newStore())
+			errs       = make(chan error, 10)
+			sut        = commands.NewConsumer(dispatcher, source, commands.ConsumerOnError(func(ctx context.Context, env commands.Envelope, err error) {
+				errs <- err
+			}))
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		})
+
+		stop := run(t, sut)
+
+		// act
+		assert.NoError(t, source.Send(t.Context(), commands.Envelope{ID: "1", CommandName: "TestCommand", Payload: []byte(`{`)}))
+		assert.NoError(t, source.Send(t.Context(), commands.Envelope{ID: "2", CommandName: "Unknown", Payload: []byte(`{}`)}))
+		<-errs
+		<-errs
+		stop()
+
+		// assert
+		assert.Equal(t, 2, len(source.DeadLetters()))
+	})
+
+	t.Run("keep order per entity", func(t *testing.T) {
+		// arrange
+		var (
+			source     = commands.NewChannelSource()
+			dispatcher = commands.NewDispatcher(newStore())
+			sut        = commands.NewConsumer(dispatcher, source, commands.ConsumerPartitions(4))
+			mux        sync.Mutex
+			got        = make(map[string][]string)
+			wg         sync.WaitGroup
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			defer wg.Done()
+			entityID, value, _ := strings.Cut(cmd.Value, ":")
+			mux.Lock()
+			got[entityID] = append(got[entityID], value)
+			mux.Unlock()
+			return nil, nil
+		})
+
+		stop := run(t, sut)
+		defer stop()
+
+		// act
+		for _, value := range []string{"1", "2", "3", "4", "5"} {
+			for _, entityID := range []string{"a", "b", "c"} {
+				wg.Add(1)
+				assert.NoError(t, source.Send(t.Context(), envelope(t, dispatcher, entityID, TestCommand{Value: entityID + ":" + value})))
+			}
+		}
+		wg.Wait()
+
+		// assert
+		for _, entityID := range []string{"a", "b", "c"} {
+			assert.EqualSlice(t, []string{"1", "2", "3", "4", "5"}, got[entityID])
+		}
+	})
+
+	t.Run("keep order per entity when retrying", func(t *testing.T) {
+		// arrange
+		var (
+			source     = commands.NewChannelSource(commands.ChannelSourceRetryDelay(time.Millisecond))
+			dispatcher = commands.NewDispatcher(newStore())
+			sut        = commands.NewConsumer(dispatcher, source, commands.ConsumerPartitions(4))
+			received   = make(chan string, 10)
+			failed     = false
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			received <- cmd.Value
+			if cmd.Value == "1" && !failed {
+				failed = true
+				return nil, errors.New("executor-error")
+			}
+			return nil, nil
+		})
+
+		stop := run(t, sut)
+		defer stop()
+
+		// act
+		for _, value := range []string{"1", "2", "3"} {
+			assert.NoError(t, source.Send(t.Context(), envelope(t, dispatcher, "account-1", TestCommand{Value: value})))
+		}
+
+		// assert
+		var got []string
+		for range 4 {
+			got = append(got, <-received)
+		}
+		assert.EqualSlice(t, []string{"1", "1", "2", "3"}, got)
+	})
+
+	t.Run("dispatch envelope received on shutdown", func(t *testing.T) {
+		// arrange
+		var (
+			ctx, cancel = context.WithCancel(t.Context())
+			channel     = commands.NewChannelSource()
+			source      = &cancelSource{ChannelSource: channel, cancel: cancel}
+			dispatcher  = commands.NewDispatcher(newStore())
+			sut         = commands.NewConsumer(dispatcher, source)
+			received    []string
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			received = append(received, cmd.Value)
+			return nil, nil
+		})
+		assert.NoError(t, channel.Send(t.Context(), envelope(t, dispatcher, "account-1", TestCommand{Value: "1"})))
+
+		// act
+		err := sut.Run(ctx)
+
+		// assert
+		assert.NoError(t, err)
+		assert.EqualSlice(t, []string{"1"}, received)
+		assert.NoError(t, channel.Send(t.Context(), envelope(t, dispatcher, "account-1", TestCommand{Value: "2"})))
+		next, cancelNext := context.WithTimeout(t.Context(), 20*time.Millisecond)
+		defer cancelNext()
+		env, err := channel.Receive(next)
+		assert.NoError(t, err)
+		assert.Equal(t, "account-1", env.EntityID)
+	})
+
+	t.Run("finish command in flight on shutdown", func(t *testing.T) {
+		// arrange
+		var (
+			source     = commands.NewChannelSource()
+			dispatcher = commands.NewDispatcher(newStore())
+			sut        = commands.NewConsumer(dispatcher, source)
+			started    = make(chan struct{})
+			finished   = false
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			close(started)
+			time.Sleep(10 * time.Millisecond)
+			finished = ctx.Err() == nil
+			return nil, nil
+		})
+
+		stop := run(t, sut)
+		assert.NoError(t, source.Send(t.Context(), envelope(t, dispatcher, "account-1", TestCommand{})))
+		<-started
+
+		// act
+		stop()
+
+		// assert
+		assert.Truef(t, finished, "expected command to finish")
+		assert.Equal(t, 0, len(source.DeadLetters()))
+	})
+}
+
+// cancelSource cancels the context of the Consumer once an Envelope is received.
+type cancelSource struct {
+	*commands.ChannelSource
+	cancel func()
+}
+
+func (s *cancelSource) Receive(ctx context.Context) (commands.Envelope, error) {
+	env, err := s.ChannelSource.Receive(ctx)
+	s.cancel()
+	return env, err
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/kyuff/es"
+)
+
+// RegisterDecider registers a command using pure functions over a value typed state.
+// The state starts as the zero value of S and is folded through evolve for each event in the
+// stream. Decide returns the events of the command given the current state.
+func RegisterDecider[C Command, S any, E es.Content](dispatcher *Dispatcher, entityType string, decide func(cmd C, state S) ([]E, error), evolve func(state S, event E) S, opts ...RegisterOption) error {
+	var (
+		newState = func() *deciderState[S, E] {
+			return &deciderState[S, E]{evolve: evolve}
+		}
+		executor = ExecutorFunc[C, *deciderState[S, E]](func(ctx context.Context, cmd C, state *deciderState[S, E]) ([]es.Content, error) {
+			events, err := decide(cmd, state.state)
+			if err != nil {
+				return nil, err
+			}
+
+			var contents = make([]es.Content, 0, len(events))
+			for _, event := range events {
+				contents = append(contents, event)
+			}
+
+			return contents, nil
+		})
+	)
+
+	return register(dispatcher, entityType, newState, executor, false, append(slices.Clone(opts), withTypes(reflect.TypeFor[C](), reflect.TypeFor[S]()))...)
+}
+
+// deciderState folds the events of a stream through evolve.
+type deciderState[S any, E es.Content] struct {
+	state  S
+	evolve func(state S, event E) S
+}
+
+func (s *deciderState[S, E]) Handle(ctx context.Context, event es.Event) error {
+	content, ok := event.Content.(E)
+	if !ok {
+		return fmt.Errorf("event %q is %T, expected %s", event.Content.EventName(), event.Content, reflect.TypeFor[E]())
+	}
+
+	s.state = s.evolve(s.state, content)
+	return nil
+}
@@ -0,0 +1,112 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es/storage/inmemory"
+)
+
+func TestRegisterDecider(t *testing.T) {
+	var (
+		newDispatcher = func(t *testing.T) *commands.Dispatcher {
+			var storage = inmemory.New()
+			assert.NoError(t, storage.Register("account", TestEvent{}))
+			return commands.NewDispatcher(es.NewStore(storage))
+		}
+		errDuplicate = errors.New("duplicate")
+		decide       = func(cmd TestCommand, state []string) ([]TestEvent, error) {
+			if slices.Contains(state, cmd.Value) {
+				return nil, errDuplicate
+			}
+			return []TestEvent{{Value: cmd.Value}}, nil
+		}
+		evolve = func(state []string, event TestEvent) []string {
+			return append(state, event.Value)
+		}
+	)
+
+	t.Run("decide with evolved state", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = newDispatcher(t)
+			states     [][]string
+		)
+		assert.NoError(t, commands.RegisterDecider(dispatcher, "account", func(cmd TestCommand, state []string) ([]TestEvent, error) {
+			states = append(states, state)
+			return decide(cmd, state)
+		}, evolve))
+
+		// act
+		assert.NoError(t, dispatcher.Dispatch(t.Context(), "account-1", TestCommand{Value: "first"}))
+		assert.NoError(t, dispatcher.Dispatch(t.Context(), "account-1", TestCommand{Value: "second"}))
+		result, err := dispatcher.DispatchResult(t.Context(), "account-1", TestCommand{Value: "third"})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), result.Position)
+		if assert.Equal(t, 3, len(states)) {
+			assert.EqualSlice(t, nil, states[0])
+			assert.EqualSlice(t, []string{"first"}, states[1])
+			assert.EqualSlice(t, []string{"first", "second"}, states[2])
+		}
+	})
+
+	t.Run("fail when decide fails", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+		assert.NoError(t, commands.RegisterDecider(dispatcher, "account", decide, evolve))
+		assert.NoError(t, dispatcher.Dispatch(t.Context(), "account-1", TestCommand{Value: "first"}))
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{Value: "first"})
+
+		// assert
+		assert.Truef(t, errors.Is(err, errDuplicate), "expected decide error, got %v", err)
+	})
+
+	t.Run("fail on events of other types", func(t *testing.T) {
+		// arrange
+		var (
+			store = &StoreMock{
+				OpenFunc: func(ctx context.Context, entityType string, entityID string) es.Stream {
+					return &StreamMock{
+						ProjectFunc: func(handler es.Handler) error {
+							return handler.Handle(ctx, es.Event{Content: &ContentMock{EventNameFunc: func() string {
+								return "OtherEvent"
+							}}})
+						},
+						CloseFunc: func() error {
+							return nil
+						},
+					}
+				},
+			}
+			dispatcher = commands.NewDispatcher(store)
+		)
+		assert.NoError(t, commands.RegisterDecider(dispatcher, "account", decide, evolve))
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{Value: "first"})
+
+		// assert
+		assert.Match(t, `event "OtherEvent" is \*commands_test.ContentMock, expected commands_test.TestEvent`, err.Error())
+	})
+
+	t.Run("fail registering twice", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+		assert.NoError(t, commands.RegisterDecider(dispatcher, "account", decide, evolve))
+
+		// act
+		err := commands.RegisterDecider(dispatcher, "account", decide, evolve)
+
+		// assert
+		assert.Error(t, err)
+	})
+}
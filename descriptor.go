@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// Descriptor describes a registered command.
+type Descriptor struct {
+	Name        string
+	EntityType  string
+	CommandType reflect.Type
+	// StateType is the state the command is executed on. For deciders it is the value type of the state.
+	StateType reflect.Type
+	// Middlewares wrapping the command, outermost first.
+	Middlewares []Middleware
+	Timeout     time.Duration
+	Codec       Codec
+	// Versions are the versioned names upcast to the command, ordered by name.
+	Versions []string
+}
+
+// Commands returns the descriptors of the registered commands ordered by name.
+func (d *Dispatcher) Commands() []Descriptor {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+
+	var descriptors = make([]Descriptor, 0, len(d.executors))
+	for _, name := range slices.Sorted(maps.Keys(d.executors)) {
+		descriptors = append(descriptors, d.descriptor(d.executors[name]))
+	}
+
+	return descriptors
+}
+
+// Has reports whether a command is registered by name.
+func (d *Dispatcher) Has(name string) bool {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+
+	_, ok := d.executors[name]
+	return ok
+}
+
+// Lookup returns the Descriptor of the command registered by name.
+func (d *Dispatcher) Lookup(name string) (Descriptor, bool) {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+
+	reg, ok := d.executors[name]
+	if !ok {
+		return Descriptor{}, false
+	}
+
+	return d.descriptor(reg), true
+}
+
+// descriptor must be called with the lock of the dispatcher held.
+func (d *Dispatcher) descriptor(reg *registration) Descriptor {
+	var middlewares = slices.Clone(reg.middlewares)
+	slices.Reverse(middlewares)
+
+	return Descriptor{
+		Name:        reg.name,
+		EntityType:  reg.entityType,
+		CommandType: reg.commandType,
+		StateType:   reg.stateType,
+		Middlewares: middlewares,
+		Timeout:     reg.timeout,
+		Codec:       reg.codec,
+		Versions:    d.versions(reg.name),
+	}
+}
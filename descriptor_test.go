@@ -0,0 +1,117 @@
+package commands_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestDescriptor(t *testing.T) {
+	var (
+		noop = func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		}
+	)
+
+	t.Run("list registered commands by name", func(t *testing.T) {
+		// arrange
+		var dispatcher = commands.NewDispatcher(&StoreMock{})
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd *TestPointerCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		})
+		_ = commands.RegisterFunc(dispatcher, "account", noop)
+		_ = commands.RegisterDecider(dispatcher, "other", func(cmd TestCompensateCommand, state []string) ([]TestEvent, error) {
+			return nil, nil
+		}, func(state []string, event TestEvent) []string {
+			return state
+		})
+
+		// act
+		got := dispatcher.Commands()
+
+		// assert
+		if assert.Equal(t, 3, len(got)) {
+			assert.Equal(t, "TestCommand", got[0].Name)
+			assert.Equal(t, "TestCompensateCommand", got[1].Name)
+			assert.Equal(t, "TestPointerCommand", got[2].Name)
+			assert.Equal(t, reflect.TypeFor[*TestPointerCommand](), got[2].CommandType)
+			assert.Equal(t, reflect.TypeFor[*StateMock](), got[2].StateType)
+			assert.Equal(t, "other", got[1].EntityType)
+			assert.Equal(t, reflect.TypeFor[[]string](), got[1].StateType)
+		}
+	})
+
+	t.Run("describe options and middlewares", func(t *testing.T) {
+		// arrange
+		var (
+			first = commands.MiddlewareFunc(func(next func(ctx context.Context, command commands.Command) error) func(ctx context.Context, command commands.Command) error {
+				return next
+			})
+			second = commands.MiddlewareFunc(func(next func(ctx context.Context, command commands.Command) error) func(ctx context.Context, command commands.Command) error {
+				return func(ctx context.Context, command commands.Command) error {
+					return next(ctx, command)
+				}
+			})
+			dispatcher = commands.New(&StoreMock{}, commands.WithMiddlewares(first, second))
+		)
+		_ = commands.RegisterFunc(dispatcher, "account", noop, commands.WithTimeout(time.Second), commands.WithCommandCodec(commands.GobCodec{}))
+
+		// act
+		got, ok := dispatcher.Lookup("TestCommand")
+
+		// assert
+		assert.Truef(t, ok, "expected TestCommand to be found")
+		assert.Equal(t, "TestCommand", got.Name)
+		assert.Equal(t, "account", got.EntityType)
+		assert.Equal(t, reflect.TypeFor[TestCommand](), got.CommandType)
+		assert.Equal(t, time.Second, got.Timeout)
+		assert.Equal(t, commands.Codec(commands.GobCodec{}), got.Codec)
+		if assert.Equal(t, 2, len(got.Middlewares)) {
+			assert.Equal(t, reflect.ValueOf(first).Pointer(), reflect.ValueOf(got.Middlewares[0]).Pointer())
+			assert.Equal(t, reflect.ValueOf(second).Pointer(), reflect.ValueOf(got.Middlewares[1]).Pointer())
+		}
+	})
+
+	t.Run("describe aggregate commands", func(t *testing.T) {
+		// arrange
+		var dispatcher = commands.NewDispatcher(&StoreMock{})
+		_ = commands.RegisterAggregate[*TestAggregate](dispatcher, "account")
+
+		// act
+		got, ok := dispatcher.Lookup("TestPointerCommand")
+
+		// assert
+		assert.Truef(t, ok, "expected TestPointerCommand to be found")
+		assert.Equal(t, reflect.TypeFor[*TestPointerCommand](), got.CommandType)
+		assert.Equal(t, reflect.TypeFor[*TestAggregate](), got.StateType)
+	})
+
+	t.Run("lookup unknown command", func(t *testing.T) {
+		// arrange
+		var dispatcher = commands.NewDispatcher(&StoreMock{})
+
+		// act
+		_, ok := dispatcher.Lookup("TestCommand")
+
+		// assert
+		assert.Truef(t, !ok, "expected TestCommand not to be found")
+	})
+
+	t.Run("has registered command", func(t *testing.T) {
+		// arrange
+		var dispatcher = commands.NewDispatcher(&StoreMock{})
+		_ = commands.RegisterFunc(dispatcher, "account", noop)
+
+		// act
+		has, hasNot := dispatcher.Has("TestCommand"), dispatcher.Has("TestPointerCommand")
+
+		// assert
+		assert.Truef(t, has, "expected TestCommand to be registered")
+		assert.Truef(t, !hasNot, "expected TestPointerCommand not to be registered")
+	})
+}
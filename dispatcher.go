@@ -2,30 +2,119 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"slices"
 	"sync"
+	"time"
 
 	"github.com/kyuff/es"
 )
 
+var ErrNotRegistered = errors.New("command not registered")
+
 type Store interface {
 	Open(ctx context.Context, entityType string, entityID string) es.Stream
 }
 
+type Option func(cfg *config)
+
+type config struct {
+	middlewares    []Middleware
+	defaultTimeout time.Duration
+	codec          Codec
+	scheduler      Scheduler
+	publisher      Publisher
+	tenants        *tenantConfig
+	journal        Journal
+	onError        func(ctx context.Context, err error)
+	// causationDepths is the number of events the causation depth is remembered for.
+	causationDepths int
+}
+
+func WithMiddlewares(middlewares ...Middleware) Option {
+	return func(cfg *config) {
+		cfg.middlewares = append(cfg.middlewares, middlewares...)
+	}
+}
+
+// WithDefaultTimeout sets the timeout for commands that are not registered with their own.
+func WithDefaultTimeout(timeout time.Duration) Option {
+	return func(cfg *config) {
+		cfg.defaultTimeout = timeout
+	}
+}
+
+// WithCodec sets the Codec used by Decode and Encode for commands not registered with their own.
+// Defaults to JSONCodec.
+func WithCodec(codec Codec) Option {
+	return func(cfg *config) {
+		cfg.codec = codec
+	}
+}
+
+// WithOnError sets the func called with errors that happen after a command is dispatched,
+// such as a PublishError or a JournalError. They do not fail the command.
+func WithOnError(fn func(ctx context.Context, err error)) Option {
+	return func(cfg *config) {
+		cfg.onError = fn
+	}
+}
+
+var _ Registry = (*Dispatcher)(nil)
+
+// NewDispatcher creates a Dispatcher with the middlewares. Use New to configure it with Options.
 func NewDispatcher(store Store, middlewares ...Middleware) *Dispatcher {
-	slices.Reverse(middlewares)
+	return New(store, WithMiddlewares(middlewares...))
+}
+
+// New creates a Dispatcher configured with the options.
+func New(store Store, opts ...Option) *Dispatcher {
+	var cfg = &config{
+		codec:           JSONCodec{},
+		onError:         func(ctx context.Context, err error) {},
+		causationDepths: 10000,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.tenants != nil {
+		store = &TenantStore{store: store, cfg: cfg.tenants}
+	}
+
+	slices.Reverse(cfg.middlewares)
 	return &Dispatcher{
-		store:       store,
-		executors:   make(map[string]func(ctx context.Context, entityID string, cmd Command) error),
-		middlewares: middlewares,
+		store:      store,
+		cfg:        cfg,
+		executors:  make(map[string]*registration),
+		causation:  newCausationDepths(cfg.causationDepths),
+		upcasters:  make(map[string]*upcaster),
+		upcastFrom: make(map[reflect.Type]*upcaster),
 	}
 }
 
 type Dispatcher struct {
-	store       Store
-	mux         sync.RWMutex
-	executors   map[string]func(ctx context.Context, entityID string, cmd Command) error
+	store     Store
+	cfg       *config
+	mux       sync.RWMutex
+	executors map[string]*registration
+	causation *causationDepths
+	// upcasters of previous versions of commands by versioned name and by type.
+	upcasters  map[string]*upcaster
+	upcastFrom map[reflect.Type]*upcaster
+}
+
+type registration struct {
+	name        string
+	entityType  string
+	timeout     time.Duration
+	execute     func(ctx context.Context, entityID string, cmd Command) error
+	decode      func(decode func(v any) error) (Command, error)
+	codec       Codec
+	commandType reflect.Type
+	stateType   reflect.Type
 	middlewares []Middleware
 }
 
@@ -34,13 +123,80 @@ func (d *Dispatcher) Dispatch(ctx context.Context, entityID string, cmd Command)
 		return fmt.Errorf("command %T is nil", cmd)
 	}
 
+	if d.cfg.journal != nil {
+		return d.journal(ctx, entityID, cmd)
+	}
+
+	return d.dispatch(ctx, entityID, cmd)
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, entityID string, cmd Command) error {
+	if d.cfg.tenants != nil && d.cfg.tenants.strict {
+		if _, ok := d.cfg.tenants.resolve(ctx); !ok {
+			return fmt.Errorf("command %s: %w", cmd.CommandName(), ErrNoTenant)
+		}
+	}
+
+	reg, cmd, err := d.lookup(cmd)
+	if err != nil {
+		return err
+	}
+
+	// the lock is not held while executing, so registrations can change with dispatches in flight.
+	return reg.execute(ctx, entityID, cmd)
+}
+
+// lookup the registration of the command, upcasting previous versions of it.
+func (d *Dispatcher) lookup(cmd Command) (*registration, Command, error) {
+	reg, chain, err := d.registration(cmd.CommandName())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if chain != nil {
+		cmd, err = upcast(chain, cmd)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return reg, cmd, nil
+}
+
+// EntityType returns the entity type the command name is registered with.
+// Versioned names of the command are registered with the same entity type.
+func (d *Dispatcher) EntityType(name string) (string, bool) {
 	d.mux.RLock()
 	defer d.mux.RUnlock()
 
-	executor, ok := d.executors[cmd.CommandName()]
+	reg, ok := d.executors[name]
 	if !ok {
-		return fmt.Errorf("command %s not registered", cmd.CommandName())
+		_, versionReg, err := d.version(name)
+		if err != nil {
+			return "", false
+		}
+		reg = versionReg
+	}
+
+	return reg.entityType, true
+}
+
+// DecodeFunc creates a Command of the type registered by name and fills it using decode,
+// which is given a pointer to the Command, such as json.Decoder.Decode.
+func (d *Dispatcher) DecodeFunc(name string, decode func(v any) error) (Command, error) {
+	reg, chain, err := d.registration(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if chain != nil {
+		return decodeVersion(chain, decode)
+	}
+
+	cmd, err := reg.decode(decode)
+	if err != nil {
+		return nil, fmt.Errorf("decode command %s: %w: %w", name, ErrInvalidCommand, err)
 	}
 
-	return executor(ctx, entityID, cmd)
+	return cmd, nil
 }
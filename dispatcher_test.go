@@ -2,6 +2,7 @@ package commands_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand/v2"
@@ -329,3 +330,71 @@ func TestDispatcher(t *testing.T) {
 
 	})
 }
+
+func TestDispatcherDecodeFunc(t *testing.T) {
+	var (
+		dispatcher = commands.NewDispatcher(&StoreMock{})
+		decode     = func(value string) func(v any) error {
+			return func(v any) error {
+				return json.Unmarshal([]byte(`{"Value": "`+value+`"}`), v)
+			}
+		}
+	)
+
+	_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+		return nil, nil
+	})
+	_ = commands.RegisterFunc(dispatcher, "customer", func(ctx context.Context, cmd *TestPointerCommand, state *StateMock) ([]es.Content, error) {
+		return nil, nil
+	})
+
+	t.Run("decode value command", func(t *testing.T) {
+		// act
+		got, err := dispatcher.DecodeFunc("TestCommand", decode("value"))
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal[commands.Command](t, TestCommand{Value: "value"}, got)
+	})
+
+	t.Run("decode pointer command", func(t *testing.T) {
+		// act
+		got, err := dispatcher.DecodeFunc("TestPointerCommand", decode("pointer"))
+
+		// assert
+		assert.NoError(t, err)
+		cmd, ok := got.(*TestPointerCommand)
+		if assert.Truef(t, ok, "expected *TestPointerCommand, got %T", got) {
+			assert.Equal(t, "pointer", cmd.Value)
+		}
+	})
+
+	t.Run("fail with unregistered command", func(t *testing.T) {
+		// act
+		_, err := dispatcher.DecodeFunc("Unknown", decode("value"))
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNotRegistered), "expected ErrNotRegistered, got %v", err)
+	})
+
+	t.Run("fail with decode error", func(t *testing.T) {
+		// act
+		_, err := dispatcher.DecodeFunc("TestCommand", func(v any) error {
+			return errors.New("decode-error")
+		})
+
+		// assert
+		assert.Error(t, err)
+	})
+
+	t.Run("return entity type", func(t *testing.T) {
+		// act
+		got, ok := dispatcher.EntityType("TestPointerCommand")
+		_, unknown := dispatcher.EntityType("Unknown")
+
+		// assert
+		assert.Truef(t, ok, "expected registered command")
+		assert.Equal(t, "customer", got)
+		assert.Truef(t, !unknown, "expected unknown command")
+	})
+}
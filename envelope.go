@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// Envelope is an encoded command on its way to a Dispatcher, such as through a Source.
+type Envelope struct {
+	ID          string
+	CommandName string
+	EntityID    string
+	Payload     []byte
+	Metadata    Metadata
+	// Attempts is the number of times the command has failed before.
+	Attempts int
+}
+
+// Envelope encodes the command with the Codec it is registered with, using the Metadata
+// in the context. The Envelope is given a new ID.
+func (d *Dispatcher) Envelope(ctx context.Context, entityID string, cmd Command) (Envelope, error) {
+	payload, err := d.Encode(cmd)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return Envelope{}, fmt.Errorf("envelope id: %w", err)
+	}
+
+	return Envelope{
+		ID:          id.String(),
+		CommandName: cmd.CommandName(),
+		EntityID:    entityID,
+		Payload:     payload,
+		Metadata:    MetadataFromContext(ctx),
+	}, nil
+}
+
+// DispatchEnvelope decodes the command in the Envelope and dispatches it with its Metadata.
+// Envelopes that fail to decode are recorded in the Journal as rejected.
+func (d *Dispatcher) DispatchEnvelope(ctx context.Context, env Envelope) error {
+	if len(env.Metadata) > 0 {
+		ctx = ContextWithMetadata(ctx, env.Metadata)
+	}
+
+	cmd, err := d.Decode(env.CommandName, env.Payload)
+	if err != nil {
+		return d.Reject(ctx, env.EntityID, env.CommandName, env.Payload, err)
+	}
+
+	return d.Dispatch(ctx, env.EntityID, cmd)
+}
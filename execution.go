@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/kyuff/es"
+)
+
+type executionKey struct{}
+
+type execution struct {
+	entityType string
+	entityID   string
+	events     []es.Content
+	phase      Phase
+	result     *Result
+}
+
+func withExecution(ctx context.Context, exec *execution) context.Context {
+	return context.WithValue(ctx, executionKey{}, exec)
+}
+
+func executionFrom(ctx context.Context) (*execution, bool) {
+	exec, ok := ctx.Value(executionKey{}).(*execution)
+	return exec, ok
+}
+
+func (exec *execution) record(stream es.Stream, events []es.Content) {
+	exec.events = events
+	if exec.result != nil {
+		exec.result.Position = stream.Position()
+		exec.result.Events = events
+	}
+}
@@ -22,39 +22,55 @@ func (fn ExecutorFunc[C, S]) Execute(ctx context.Context, cmd C, state S) ([]es.
 	return fn(ctx, cmd, state)
 }
 
-func decorateExecutor[C Command, S State](store Store, entityType string, executor Executor[C, S]) func(ctx context.Context, entityID string, command Command) error {
-	var newStateFunc = newInstance[S]()
+func decorateExecutor[C Command, S State](store Store, cfg *config, entityType string, newState func() S, executor Executor[C, S]) func(ctx context.Context, entityID string, command Command) error {
 	return func(ctx context.Context, entityID string, command Command) error {
 		cmd, ok := command.(C)
 		if !ok {
 			return fmt.Errorf("command %q is %T, expected %T", command.CommandName(), command, cmd)
 		}
 
+		exec, ok := executionFrom(ctx)
+		if !ok {
+			exec = &execution{entityType: entityType, entityID: entityID}
+		}
+
+		exec.phase = PhaseProject
 		stream := store.Open(ctx, entityType, entityID)
 		defer func() {
 			_ = stream.Close()
 		}()
 
-		var state = newStateFunc()
+		var state = newState()
 		err := stream.Project(state)
 		if err != nil {
 			return err
 		}
 
+		exec.phase = PhaseExecute
 		events, err := executor.Execute(ctx, cmd, state)
 		if err != nil {
 			return err
 		}
 
 		if len(events) == 0 {
+			exec.record(stream, nil)
 			return nil
 		}
 
+		exec.phase = PhaseWrite
+		tenant, streamType := cfg.tenants.stream(ctx, entityType)
+		recordWritten := recordCausation(ctx, cfg.tenants.store(ctx), streamType, entityID, stream)
 		err = stream.Write(events...)
 		if err != nil {
 			return err
 		}
 
+		recordWritten(len(events))
+
+		exec.record(stream, events)
+
+		publish(ctx, cfg, stream, tenant, streamType, entityID, command, events)
+
 		return nil
 	}
 }
@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrJournal = errors.New("journal")
+
+// JournalError is reported to the func set by WithOnError when a dispatched command could not
+// be recorded in the Journal. The outcome of the command is returned by Dispatch as is.
+type JournalError struct {
+	CommandName string
+	EntityID    string
+	Err         error
+}
+
+func (e *JournalError) Error() string {
+	return fmt.Sprintf("journal command %s for %q: %s", e.CommandName, e.EntityID, e.Err)
+}
+
+func (e *JournalError) Unwrap() []error {
+	return []error{ErrJournal, e.Err}
+}
+
+func (e *JournalError) ErrorKind() string {
+	return "journal"
+}
+
+type Outcome string
+
+const (
+	// OutcomeSucceeded is a command that was executed, and its events written.
+	OutcomeSucceeded Outcome = "succeeded"
+	// OutcomeRejected is a command that was refused by the dispatcher, its middleware or its executor.
+	OutcomeRejected Outcome = "rejected"
+	// OutcomeFailed is a command that failed to read or write its stream.
+	OutcomeFailed Outcome = "failed"
+)
+
+// JournalEntry is the record of a dispatched command.
+type JournalEntry struct {
+	CommandName string `json:"command_name"`
+	EntityType  string `json:"entity_type,omitempty"`
+	EntityID    string `json:"entity_id"`
+	// Payload of the command encoded with the Codec it is registered with.
+	Payload []byte `json:"payload,omitempty"`
+	// PayloadError is why the command could not be encoded, leaving Payload empty.
+	PayloadError string        `json:"payload_error,omitempty"`
+	Metadata     Metadata      `json:"metadata,omitempty"`
+	Outcome      Outcome       `json:"outcome"`
+	Error        string        `json:"error,omitempty"`
+	ErrorKind    string        `json:"error_kind,omitempty"`
+	Time         time.Time     `json:"time"`
+	Duration     time.Duration `json:"duration"`
+	// Position of the stream after the command was executed.
+	Position int64 `json:"position,omitempty"`
+	// Events are the names of the events written by the command.
+	Events []string `json:"events,omitempty"`
+}
+
+// Journal records every command dispatched, whether it succeeded or not.
+type Journal interface {
+	Record(ctx context.Context, entry JournalEntry) error
+}
+
+type JournalFunc func(ctx context.Context, entry JournalEntry) error
+
+func (fn JournalFunc) Record(ctx context.Context, entry JournalEntry) error {
+	return fn(ctx, entry)
+}
+
+// WithJournal records the commands dispatched in the Journal after each dispatch. When the
+// Journal fails, a JournalError is reported to the func set by WithOnError.
+func WithJournal(journal Journal) Option {
+	return func(cfg *config) {
+		cfg.journal = journal
+	}
+}
+
+type journalKey struct{}
+
+// journalRecord is filled by the execution of a command dispatched with a Journal.
+type journalRecord struct {
+	entityType string
+	phase      Phase
+}
+
+// takeJournalRecord returns the record requested by the Journal and hides it from
+// commands dispatched further down the same context.
+func takeJournalRecord(ctx context.Context) (context.Context, *journalRecord) {
+	rec, ok := ctx.Value(journalKey{}).(*journalRecord)
+	if !ok || rec == nil {
+		return ctx, nil
+	}
+
+	return context.WithValue(ctx, journalKey{}, (*journalRecord)(nil)), rec
+}
+
+// journal dispatches the command and records it in the Journal.
+func (d *Dispatcher) journal(ctx context.Context, entityID string, cmd Command) error {
+	var (
+		start  = time.Now()
+		rec    = &journalRecord{}
+		result *Result
+	)
+	if requested, ok := ctx.Value(resultKey{}).(*Result); ok && requested != nil {
+		result = requested
+	} else {
+		result = &Result{}
+	}
+
+	err := d.dispatch(context.WithValue(context.WithValue(ctx, resultKey{}, result), journalKey{}, rec), entityID, cmd)
+
+	var entry = JournalEntry{
+		CommandName: cmd.CommandName(),
+		EntityType:  rec.entityType,
+		EntityID:    entityID,
+		Metadata:    MetadataFromContext(ctx),
+		Outcome:     OutcomeSucceeded,
+		Time:        start,
+		Duration:    time.Since(start),
+		Position:    result.Position,
+	}
+	payload, payloadErr := d.journalPayload(cmd)
+	if payloadErr != nil {
+		entry.PayloadError = payloadErr.Error()
+	} else {
+		entry.Payload = payload
+	}
+	for _, event := range result.Events {
+		entry.Events = append(entry.Events, event.EventName())
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		entry.ErrorKind = errorKind(err)
+		entry.Outcome = OutcomeRejected
+		if rec.phase == PhaseProject || rec.phase == PhaseWrite {
+			entry.Outcome = OutcomeFailed
+		}
+	}
+
+	d.record(ctx, entry)
+
+	return err
+}
+
+// Reject records a command that never reached Dispatch in the Journal, such as when it failed
+// to decode or is not registered, with the payload as it was received. It returns err, so
+// transports and sources can record a command where they give up on it.
+func (d *Dispatcher) Reject(ctx context.Context, entityID, commandName string, payload []byte, err error) error {
+	if d.cfg.journal == nil {
+		return err
+	}
+
+	entityType, _ := d.EntityType(commandName)
+	d.record(ctx, JournalEntry{
+		CommandName: commandName,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Payload:     payload,
+		Metadata:    MetadataFromContext(ctx),
+		Outcome:     OutcomeRejected,
+		Error:       err.Error(),
+		ErrorKind:   errorKind(err),
+		Time:        time.Now(),
+	})
+
+	return err
+}
+
+// record the entry in the Journal, reporting a failure with onError.
+func (d *Dispatcher) record(ctx context.Context, entry JournalEntry) {
+	ctx = context.WithoutCancel(ctx)
+	err := d.cfg.journal.Record(ctx, entry)
+	if err != nil {
+		d.cfg.onError(ctx, &JournalError{CommandName: entry.CommandName, EntityID: entry.EntityID, Err: err})
+	}
+}
+
+// journalPayload encodes the command with its Codec, or the Codec of the Dispatcher for commands
+// that are not registered.
+func (d *Dispatcher) journalPayload(cmd Command) ([]byte, error) {
+	d.mux.RLock()
+	var codec = d.cfg.codec
+	if reg, ok := d.executors[cmd.CommandName()]; ok {
+		codec = reg.codec
+	}
+	d.mux.RUnlock()
+
+	return codec.Marshal(cmd)
+}
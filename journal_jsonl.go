@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+var _ Journal = (*JSONLinesJournal)(nil)
+
+// NewJSONLinesJournal creates a Journal writing each entry as a line of JSON to w.
+func NewJSONLinesJournal(w io.Writer) *JSONLinesJournal {
+	return &JSONLinesJournal{w: w}
+}
+
+// OpenJSONLinesJournal creates a Journal appending to the file at path, creating it if needed.
+func OpenJSONLinesJournal(path string) (*JSONLinesJournal, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+
+	return &JSONLinesJournal{w: f, closer: f}, nil
+}
+
+type JSONLinesJournal struct {
+	mux    sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+func (j *JSONLinesJournal) Record(ctx context.Context, entry JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal journal entry: %w", err)
+	}
+
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	_, err = j.w.Write(append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("write journal entry: %w", err)
+	}
+
+	return nil
+}
+
+// Close the file of a Journal opened with OpenJSONLinesJournal.
+func (j *JSONLinesJournal) Close() error {
+	if j.closer == nil {
+		return nil
+	}
+
+	return j.closer.Close()
+}
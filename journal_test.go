@@ -0,0 +1,314 @@
+package commands_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestWithJournal(t *testing.T) {
+	var (
+		newStore = func(write func(events ...es.Content) error) *StoreMock {
+			return &StoreMock{
+				OpenFunc: func(ctx context.Context, entityType string, entityID string) es.Stream {
+					return &StreamMock{
+						ProjectFunc: func(handler es.Handler) error {
+							return nil
+						},
+						WriteFunc: write,
+						PositionFunc: func() int64 {
+							return 3
+						},
+						CloseFunc: func() error {
+							return nil
+						},
+					}
+				},
+			}
+		}
+		collect = func(entries *[]commands.JournalEntry) commands.JournalFunc {
+			return func(ctx context.Context, entry commands.JournalEntry) error {
+				*entries = append(*entries, entry)
+				return nil
+			}
+		}
+		newDispatcher = func(t *testing.T, store commands.Store, journal commands.Journal, err error) *commands.Dispatcher {
+			var dispatcher = commands.New(store, commands.WithJournal(journal))
+			assert.NoError(t, commands.RegisterFunc(dispatcher, "entity", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+				if err != nil {
+					return nil, err
+				}
+				return []es.Content{TestEvent{Value: cmd.Value}}, nil
+			}))
+			return dispatcher
+		}
+		write = func(events ...es.Content) error {
+			return nil
+		}
+	)
+
+	t.Run("record succeeded command", func(t *testing.T) {
+		// arrange
+		var (
+			entries []commands.JournalEntry
+			sut     = newDispatcher(t, newStore(write), collect(&entries), nil)
+			ctx     = commands.ContextWithMetadata(t.Context(), commands.Metadata{"user": "user-1"})
+		)
+
+		// act
+		result, err := sut.DispatchResult(ctx, "entity-1", TestCommand{Value: "value"})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), result.Position)
+		if assert.Equal(t, 1, len(entries)) {
+			assert.Equal(t, "TestCommand", entries[0].CommandName)
+			assert.Equal(t, "entity", entries[0].EntityType)
+			assert.Equal(t, "entity-1", entries[0].EntityID)
+			assert.Equal(t, `{"Value":"value"}`, string(entries[0].Payload))
+			assert.Equal(t, "user-1", entries[0].Metadata["user"])
+			assert.Equal(t, commands.OutcomeSucceeded, entries[0].Outcome)
+			assert.Equal(t, int64(3), entries[0].Position)
+			assert.EqualSlice(t, []string{"TestEvent"}, entries[0].Events)
+			assert.Truef(t, entries[0].Duration >= 0, "expected duration, got %s", entries[0].Duration)
+		}
+	})
+
+	t.Run("record rejected command", func(t *testing.T) {
+		// arrange
+		var (
+			entries []commands.JournalEntry
+			errDeny = errors.New("denied")
+			sut     = newDispatcher(t, newStore(write), collect(&entries), errDeny)
+		)
+
+		// act
+		err := sut.Dispatch(t.Context(), "entity-1", TestCommand{Value: "value"})
+
+		// assert
+		assert.Truef(t, errors.Is(err, errDeny), "expected executor error, got %v", err)
+		if assert.Equal(t, 1, len(entries)) {
+			assert.Equal(t, commands.OutcomeRejected, entries[0].Outcome)
+			assert.Equal(t, "denied", entries[0].Error)
+			assert.Equal(t, int64(0), entries[0].Position)
+			assert.Equal(t, 0, len(entries[0].Events))
+		}
+	})
+
+	t.Run("record failed command", func(t *testing.T) {
+		// arrange
+		var (
+			entries  []commands.JournalEntry
+			errWrite = errors.New("write")
+			sut      = newDispatcher(t, newStore(func(events ...es.Content) error {
+				return errWrite
+			}), collect(&entries), nil)
+		)
+
+		// act
+		err := sut.Dispatch(t.Context(), "entity-1", TestCommand{Value: "value"})
+
+		// assert
+		assert.Truef(t, errors.Is(err, errWrite), "expected write error, got %v", err)
+		if assert.Equal(t, 1, len(entries)) {
+			assert.Equal(t, commands.OutcomeFailed, entries[0].Outcome)
+			assert.Equal(t, "write", entries[0].Error)
+		}
+	})
+
+	t.Run("record commands that are not registered", func(t *testing.T) {
+		// arrange
+		var (
+			entries []commands.JournalEntry
+			sut     = newDispatcher(t, newStore(write), collect(&entries), nil)
+		)
+
+		// act
+		err := sut.Dispatch(t.Context(), "entity-1", &TestPointerCommand{Value: "value"})
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNotRegistered), "expected ErrNotRegistered, got %v", err)
+		if assert.Equal(t, 1, len(entries)) {
+			assert.Equal(t, "TestPointerCommand", entries[0].CommandName)
+			assert.Equal(t, "", entries[0].EntityType)
+			assert.Equal(t, commands.OutcomeRejected, entries[0].Outcome)
+		}
+	})
+
+	t.Run("record envelopes that fail to decode", func(t *testing.T) {
+		// arrange
+		var (
+			entries []commands.JournalEntry
+			sut     = newDispatcher(t, newStore(write), collect(&entries), nil)
+		)
+
+		// act
+		err := sut.DispatchEnvelope(t.Context(), commands.Envelope{
+			ID:          "envelope-1",
+			CommandName: "TestCommand",
+			EntityID:    "entity-1",
+			Payload:     []byte(`{`),
+			Metadata:    commands.Metadata{"user": "user-1"},
+		})
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrInvalidCommand), "expected ErrInvalidCommand, got %v", err)
+		if assert.Equal(t, 1, len(entries)) {
+			assert.Equal(t, "TestCommand", entries[0].CommandName)
+			assert.Equal(t, "entity", entries[0].EntityType)
+			assert.Equal(t, "entity-1", entries[0].EntityID)
+			assert.Equal(t, `{`, string(entries[0].Payload))
+			assert.Equal(t, "user-1", entries[0].Metadata["user"])
+			assert.Equal(t, commands.OutcomeRejected, entries[0].Outcome)
+			assert.Truef(t, entries[0].Error != "", "expected error")
+		}
+	})
+
+	t.Run("report journal failure without failing the command", func(t *testing.T) {
+		// arrange
+		var (
+			errJournal = errors.New("journal")
+			reported   []error
+			dispatcher = commands.New(newStore(write),
+				commands.WithJournal(commands.JournalFunc(func(ctx context.Context, entry commands.JournalEntry) error {
+					return errJournal
+				})),
+				commands.WithOnError(func(ctx context.Context, err error) {
+					reported = append(reported, err)
+				}),
+			)
+		)
+		assert.NoError(t, commands.RegisterFunc(dispatcher, "entity", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return []es.Content{TestEvent{Value: cmd.Value}}, nil
+		}))
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "entity-1", TestCommand{Value: "value"})
+
+		// assert
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(reported)) {
+			var journalErr *commands.JournalError
+			assert.Truef(t, errors.As(reported[0], &journalErr), "expected JournalError, got %v", reported[0])
+			assert.Truef(t, errors.Is(reported[0], commands.ErrJournal), "expected ErrJournal, got %v", reported[0])
+			assert.Truef(t, errors.Is(reported[0], errJournal), "expected journal error, got %v", reported[0])
+		}
+	})
+
+	t.Run("record when the context is cancelled after dispatch", func(t *testing.T) {
+		// arrange
+		var (
+			ctx, cancel = context.WithCancel(t.Context())
+			recordErr   error
+			sut         = newDispatcher(t, newStore(write), commands.JournalFunc(func(ctx context.Context, entry commands.JournalEntry) error {
+				recordErr = ctx.Err()
+				return nil
+			}), nil)
+		)
+		_ = commands.RegisterFunc(sut, "other", func(ctx context.Context, cmd *TestPointerCommand, state *StateMock) ([]es.Content, error) {
+			cancel()
+			return nil, nil
+		})
+
+		// act
+		err := sut.Dispatch(ctx, "entity-1", &TestPointerCommand{})
+
+		// assert
+		assert.NoError(t, err)
+		assert.NoError(t, recordErr)
+	})
+
+	t.Run("record commands that fail to encode", func(t *testing.T) {
+		// arrange
+		var (
+			entries    []commands.JournalEntry
+			dispatcher = commands.New(newStore(write), commands.WithJournal(collect(&entries)))
+		)
+		assert.NoError(t, commands.RegisterFunc(dispatcher, "entity", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		}, commands.WithCommandCodec(failingCodec{err: errors.New("encode-error")})))
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "entity-1", TestCommand{Value: "value"})
+
+		// assert
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(entries)) {
+			assert.Equal(t, "encode-error", entries[0].PayloadError)
+			assert.Equal(t, 0, len(entries[0].Payload))
+			assert.Equal(t, commands.OutcomeSucceeded, entries[0].Outcome)
+		}
+	})
+}
+
+type failingCodec struct {
+	err error
+}
+
+func (c failingCodec) Marshal(v any) ([]byte, error) {
+	return nil, c.err
+}
+
+func (c failingCodec) Unmarshal(data []byte, v any) error {
+	return c.err
+}
+
+func TestJSONLinesJournal(t *testing.T) {
+	var entry = commands.JournalEntry{
+		CommandName: "TestCommand",
+		EntityType:  "entity",
+		EntityID:    "entity-1",
+		Outcome:     commands.OutcomeSucceeded,
+		Events:      []string{"TestEvent"},
+	}
+
+	t.Run("write entries as lines", func(t *testing.T) {
+		// arrange
+		var (
+			buf bytes.Buffer
+			sut = commands.NewJSONLinesJournal(&buf)
+		)
+
+		// act
+		assert.NoError(t, sut.Record(t.Context(), entry))
+		assert.NoError(t, sut.Record(t.Context(), entry))
+
+		// assert
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if assert.Equal(t, 2, len(lines)) {
+			var got commands.JournalEntry
+			assert.NoError(t, json.Unmarshal([]byte(lines[1]), &got))
+			assert.Equal(t, "TestCommand", got.CommandName)
+			assert.Equal(t, commands.OutcomeSucceeded, got.Outcome)
+			assert.EqualSlice(t, []string{"TestEvent"}, got.Events)
+		}
+	})
+
+	t.Run("append to file", func(t *testing.T) {
+		// arrange
+		var path = filepath.Join(t.TempDir(), "journal.jsonl")
+		for range 2 {
+			sut, err := commands.OpenJSONLinesJournal(path)
+			assert.NoError(t, err)
+
+			// act
+			assert.NoError(t, sut.Record(t.Context(), entry))
+			assert.NoError(t, sut.Close())
+		}
+
+		// assert
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, strings.Count(string(data), "\n"))
+		assert.Match(t, `^\{"command_name":"TestCommand","entity_type":"entity","entity_id":"entity-1",`, string(data))
+	})
+}
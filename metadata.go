@@ -0,0 +1,38 @@
+package commands
+
+import "context"
+
+// Metadata travels with a command as an envelope, such as headers from a transport.
+type Metadata map[string]string
+
+type metadataKey struct{}
+
+func ContextWithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+func MetadataFromContext(ctx context.Context) Metadata {
+	md, _ := ctx.Value(metadataKey{}).(Metadata)
+	return md
+}
+
+// Metadata keys set on commands dispatched in reaction to events.
+const (
+	// MetadataCorrelationID links the commands of a chain, such as the steps of a process.
+	MetadataCorrelationID = "correlation_id"
+	// MetadataCausationID is the ID of the event that caused the command.
+	MetadataCausationID = "causation_id"
+)
+
+// withMetadata returns a context with a copy of the Metadata in ctx and the given key-value pairs added.
+func withMetadata(ctx context.Context, kv ...string) context.Context {
+	var md = make(Metadata)
+	for k, v := range MetadataFromContext(ctx) {
+		md[k] = v
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		md[kv[i]] = kv[i+1]
+	}
+
+	return ContextWithMetadata(ctx, md)
+}
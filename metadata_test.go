@@ -0,0 +1,29 @@
+package commands_test
+
+import (
+	"testing"
+
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	t.Run("read metadata from context", func(t *testing.T) {
+		// arrange
+		var ctx = commands.ContextWithMetadata(t.Context(), commands.Metadata{"tenant": "tenant-1"})
+
+		// act
+		got := commands.MetadataFromContext(ctx)
+
+		// assert
+		assert.Equal(t, "tenant-1", got["tenant"])
+	})
+
+	t.Run("read empty metadata", func(t *testing.T) {
+		// act
+		got := commands.MetadataFromContext(t.Context())
+
+		// assert
+		assert.Equal(t, 0, len(got))
+	})
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"errors"
 )
 
 type Middleware interface {
@@ -13,8 +14,30 @@ func (fn MiddlewareFunc) Intercept(next func(ctx context.Context, command Comman
 	return fn(next)
 }
 
-func middlewareExecutor(middlewares []Middleware, inner func(ctx context.Context, entityID string, command Command) error) func(ctx context.Context, entityID string, command Command) error {
+func middlewareExecutor(reg *registration, middlewares []Middleware, inner func(ctx context.Context, entityID string, command Command) error) func(ctx context.Context, entityID string, command Command) error {
 	return func(ctx context.Context, entityID string, command Command) error {
+		ctx, result := takeResult(ctx)
+		ctx, rec := takeJournalRecord(ctx)
+		var exec = &execution{
+			entityType: reg.entityType,
+			entityID:   entityID,
+			phase:      PhaseMiddleware,
+			result:     result,
+		}
+		if rec != nil {
+			rec.entityType = reg.entityType
+			defer func() {
+				rec.phase = exec.phase
+			}()
+		}
+
+		var timeoutCtx = withExecution(ctx, exec)
+		if reg.timeout > 0 {
+			var cancel context.CancelFunc
+			timeoutCtx, cancel = context.WithTimeout(timeoutCtx, reg.timeout)
+			defer cancel()
+		}
+
 		next := func(ctx context.Context, command Command) error {
 			return inner(ctx, entityID, command)
 		}
@@ -23,6 +46,16 @@ func middlewareExecutor(middlewares []Middleware, inner func(ctx context.Context
 			next = mw.Intercept(next)
 		}
 
-		return next(ctx, command)
+		err := next(timeoutCtx, command)
+		if err != nil && reg.timeout > 0 && ctx.Err() == nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+			return &TimeoutError{
+				Name:    reg.name,
+				Timeout: reg.timeout,
+				Phase:   exec.phase,
+				Err:     err,
+			}
+		}
+
+		return err
 	}
 }
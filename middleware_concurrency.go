@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ConcurrencyLimit allows at most limit commands per key to execute at the same time.
+// Other commands wait in a queue until a slot is free or the context is done.
+// It panics if limit is not positive.
+func ConcurrencyLimit(limit int, opts ...LimitOption) MiddlewareFunc {
+	if limit <= 0 {
+		panic(fmt.Sprintf("commands: ConcurrencyLimit must be positive, got %d", limit))
+	}
+
+	var (
+		cfg   = newLimitConfig(opts...)
+		mux   sync.Mutex
+		slots = make(map[string]*concurrencySlots)
+	)
+
+	get := func(key string) *concurrencySlots {
+		mux.Lock()
+		defer mux.Unlock()
+
+		s, ok := slots[key]
+		if !ok {
+			s = &concurrencySlots{running: make(chan struct{}, limit)}
+			slots[key] = s
+		}
+		s.users++
+
+		return s
+	}
+
+	// release the slots of a key, evicting them when no command is running or waiting.
+	release := func(key string, s *concurrencySlots) {
+		mux.Lock()
+		defer mux.Unlock()
+
+		s.users--
+		if s.users == 0 {
+			delete(slots, key)
+		}
+	}
+
+	return func(next func(ctx context.Context, command Command) error) func(ctx context.Context, command Command) error {
+		return func(ctx context.Context, command Command) error {
+			var (
+				key = cfg.key(ctx, command)
+				s   = get(key)
+			)
+			defer release(key, s)
+
+			select {
+			case s.running <- struct{}{}:
+			default:
+				if !s.enqueue(cfg.queue) {
+					return &RateLimitError{Key: key, QueueFull: true}
+				}
+
+				select {
+				case s.running <- struct{}{}:
+					s.dequeue()
+				case <-ctx.Done():
+					s.dequeue()
+					return ctx.Err()
+				}
+			}
+
+			defer func() {
+				<-s.running
+			}()
+
+			return next(ctx, command)
+		}
+	}
+}
+
+type concurrencySlots struct {
+	running chan struct{}
+	mux     sync.Mutex
+	waiting int
+	// users are the commands running or waiting, guarded by the mutex of the limit.
+	users int
+}
+
+func (s *concurrencySlots) enqueue(size int) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if size >= 0 && s.waiting >= size {
+		return false
+	}
+
+	s.waiting++
+	return true
+}
+
+func (s *concurrencySlots) dequeue() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.waiting--
+}
@@ -0,0 +1,158 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	t.Run("limit commands in flight", func(t *testing.T) {
+		// arrange
+		var (
+			running atomic.Int32
+			maxSeen atomic.Int32
+			wg      sync.WaitGroup
+			sut     = commands.ConcurrencyLimit(2).Intercept(func(ctx context.Context, command commands.Command) error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					seen := maxSeen.Load()
+					if n <= seen || maxSeen.CompareAndSwap(seen, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				return nil
+			})
+		)
+
+		// act
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, sut(t.Context(), TestCommand{}))
+			}()
+		}
+		wg.Wait()
+
+		// assert
+		assert.Equal(t, int32(2), maxSeen.Load())
+	})
+
+	t.Run("panic without slots", func(t *testing.T) {
+		assert.Panic(t, func() {
+			commands.ConcurrencyLimit(0)
+		})
+	})
+
+	t.Run("limit keys again after they are idle", func(t *testing.T) {
+		// arrange
+		var sut = commands.ConcurrencyLimit(1, commands.LimitQueue(0)).Intercept(func(ctx context.Context, command commands.Command) error {
+			return nil
+		})
+
+		// act & assert
+		for range 3 {
+			assert.NoError(t, sut(t.Context(), TestCommand{}))
+		}
+	})
+
+	t.Run("fail when queue is full", func(t *testing.T) {
+		// arrange
+		var (
+			started = make(chan struct{})
+			release = make(chan struct{})
+			sut     = commands.ConcurrencyLimit(1, commands.LimitQueue(0)).Intercept(func(ctx context.Context, command commands.Command) error {
+				close(started)
+				<-release
+				return nil
+			})
+			done = make(chan error)
+		)
+
+		go func() {
+			done <- sut(t.Context(), TestCommand{})
+		}()
+		<-started
+
+		// act
+		err := sut(t.Context(), TestCommand{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrRateLimited), "expected ErrRateLimited, got %v", err)
+		var limitErr *commands.RateLimitError
+		if assert.Truef(t, errors.As(err, &limitErr), "expected RateLimitError, got %v", err) {
+			assert.Truef(t, limitErr.QueueFull, "expected queue to be full")
+			assert.Equal(t, time.Duration(0), limitErr.RetryAfter)
+			assert.Equal(t, `command queue is full for "TestCommand"`, err.Error())
+		}
+		close(release)
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("stop waiting when context is done", func(t *testing.T) {
+		// arrange
+		var (
+			started     = make(chan struct{})
+			release     = make(chan struct{})
+			ctx, cancel = context.WithTimeout(t.Context(), time.Millisecond)
+			sut         = commands.ConcurrencyLimit(1).Intercept(func(ctx context.Context, command commands.Command) error {
+				close(started)
+				<-release
+				return nil
+			})
+			done = make(chan error)
+		)
+		defer cancel()
+
+		go func() {
+			done <- sut(t.Context(), TestCommand{})
+		}()
+		<-started
+
+		// act
+		err := sut(ctx, TestCommand{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, context.DeadlineExceeded), "expected context.DeadlineExceeded, got %v", err)
+		close(release)
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("limit keys separately", func(t *testing.T) {
+		// arrange
+		var (
+			started = make(chan struct{})
+			release = make(chan struct{})
+			sut     = commands.ConcurrencyLimit(1, commands.LimitQueue(0)).Intercept(func(ctx context.Context, command commands.Command) error {
+				if _, ok := command.(TestCommand); ok {
+					close(started)
+					<-release
+				}
+				return nil
+			})
+			done = make(chan error)
+		)
+
+		go func() {
+			done <- sut(t.Context(), TestCommand{})
+		}()
+		<-started
+
+		// act
+		err := sut(t.Context(), &TestPointerCommand{})
+
+		// assert
+		assert.NoError(t, err)
+		close(release)
+		assert.NoError(t, <-done)
+	})
+}
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrRateLimited = errors.New("rate limited")
+
+// RateLimitError is returned by the limiting middlewares when a command is rejected.
+// RetryAfter is a hint for when the same key is expected to be accepted again. It is zero
+// when the queue of a ConcurrencyLimit is full, as that depends on the commands in flight.
+type RateLimitError struct {
+	Key        string
+	RetryAfter time.Duration
+	QueueFull  bool
+}
+
+func (e *RateLimitError) Error() string {
+	if e.QueueFull {
+		return fmt.Sprintf("command queue is full for %q", e.Key)
+	}
+
+	return fmt.Sprintf("command limit reached for %q, retry after %s", e.Key, e.RetryAfter)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+func (e *RateLimitError) ErrorKind() string {
+	return "rate_limited"
+}
+
+// KeyFunc selects the key a command is limited by.
+type KeyFunc func(ctx context.Context, command Command) string
+
+func CommandNameKey(ctx context.Context, command Command) string {
+	return command.CommandName()
+}
+
+func EntityTypeKey(ctx context.Context, command Command) string {
+	if exec, ok := executionFrom(ctx); ok {
+		return exec.entityType
+	}
+
+	return ""
+}
+
+type LimitOption func(cfg *limitConfig)
+
+// LimitKey sets how commands are grouped when limited. Defaults to CommandNameKey.
+func LimitKey(fn KeyFunc) LimitOption {
+	return func(cfg *limitConfig) {
+		cfg.key = fn
+	}
+}
+
+// LimitQueue sets how many commands per key can wait for a ConcurrencyLimit.
+// Commands beyond the queue are rejected. A negative size allows an unbounded queue, which is the default.
+// RateLimit does not queue commands, and panics when given LimitQueue.
+func LimitQueue(size int) LimitOption {
+	return func(cfg *limitConfig) {
+		cfg.queue = size
+		cfg.queued = true
+	}
+}
+
+func LimitClock(now func() time.Time) LimitOption {
+	return func(cfg *limitConfig) {
+		cfg.now = now
+	}
+}
+
+type limitConfig struct {
+	key   KeyFunc
+	queue int
+	// queued is set by LimitQueue.
+	queued bool
+	now    func() time.Time
+}
+
+func newLimitConfig(opts ...LimitOption) *limitConfig {
+	cfg := &limitConfig{
+		key:   CommandNameKey,
+		queue: -1,
+		now:   time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit allows rate commands per second for each key, with bursts up to burst commands.
+// Commands over the limit are rejected with a RateLimitError. It panics if burst is not positive,
+// or if given LimitQueue, as commands over the limit are not queued.
+func RateLimit(rate float64, burst int, opts ...LimitOption) MiddlewareFunc {
+	if burst <= 0 {
+		panic(fmt.Sprintf("commands: RateLimit burst must be positive, got %d", burst))
+	}
+
+	var cfg = newLimitConfig(opts...)
+	if cfg.queued {
+		panic("commands: RateLimit does not queue commands, LimitQueue does not apply")
+	}
+
+	var (
+		mux       sync.Mutex
+		buckets   = make(map[string]*tokenBucket)
+		lastSweep = cfg.now()
+	)
+
+	take := func(key string) time.Duration {
+		mux.Lock()
+		defer mux.Unlock()
+
+		var now = cfg.now()
+		if refill, ok := refillTime(rate, burst); ok && now.Sub(lastSweep) >= refill {
+			evictFull(buckets, now, rate, float64(burst))
+			lastSweep = now
+		}
+
+		bucket, ok := buckets[key]
+		if !ok {
+			bucket = &tokenBucket{tokens: float64(burst), last: now}
+			buckets[key] = bucket
+		}
+
+		return bucket.take(now, rate, float64(burst))
+	}
+
+	return func(next func(ctx context.Context, command Command) error) func(ctx context.Context, command Command) error {
+		return func(ctx context.Context, command Command) error {
+			key := cfg.key(ctx, command)
+			if retryAfter := take(key); retryAfter > 0 {
+				return &RateLimitError{
+					Key:        key,
+					RetryAfter: retryAfter,
+				}
+			}
+
+			return next(ctx, command)
+		}
+	}
+}
+
+// refillTime is how long an empty bucket takes to be full. Buckets are never refilled without a positive rate.
+func refillTime(rate float64, burst int) (time.Duration, bool) {
+	if rate <= 0 {
+		return 0, false
+	}
+
+	return time.Duration(float64(burst) / rate * float64(time.Second)), true
+}
+
+// evictFull removes the buckets that are full by now, as they are the same as a new bucket.
+func evictFull(buckets map[string]*tokenBucket, now time.Time, rate, burst float64) {
+	for key, bucket := range buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*rate >= burst {
+			delete(buckets, key)
+		}
+	}
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take a token from the bucket. If none is available, the time until the next is returned.
+func (b *tokenBucket) take(now time.Time, rate, burst float64) time.Duration {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed.Seconds()*rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	if rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration(math.Ceil((1 - b.tokens) / rate * float64(time.Second)))
+}
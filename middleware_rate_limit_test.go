@@ -0,0 +1,175 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	var (
+		next = func(ctx context.Context, command commands.Command) error {
+			return nil
+		}
+		newClock = func() (func() time.Time, func(d time.Duration)) {
+			var now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			return func() time.Time {
+					return now
+				}, func(d time.Duration) {
+					now = now.Add(d)
+				}
+		}
+	)
+
+	t.Run("allow commands within burst", func(t *testing.T) {
+		// arrange
+		var (
+			now, _ = newClock()
+			sut    = commands.RateLimit(1, 3, commands.LimitClock(now)).Intercept(next)
+		)
+
+		// act & assert
+		for range 3 {
+			assert.NoError(t, sut(t.Context(), TestCommand{}))
+		}
+	})
+
+	t.Run("keep limiting keys that are not refilled when evicting", func(t *testing.T) {
+		// arrange
+		var (
+			now, advance = newClock()
+			key          = "first"
+			sut          = commands.RateLimit(1, 1, commands.LimitClock(now), commands.LimitKey(func(ctx context.Context, command commands.Command) string {
+				return key
+			})).Intercept(next)
+		)
+		assert.NoError(t, sut(t.Context(), TestCommand{}))
+		advance(500 * time.Millisecond)
+		key = "second"
+		assert.NoError(t, sut(t.Context(), TestCommand{}))
+		advance(600 * time.Millisecond)
+
+		// act
+		err := sut(t.Context(), TestCommand{})
+
+		// assert
+		var limitErr *commands.RateLimitError
+		if assert.Truef(t, errors.As(err, &limitErr), "expected RateLimitError, got %v", err) {
+			assert.Equal(t, 400*time.Millisecond, limitErr.RetryAfter)
+		}
+		key = "first"
+		assert.NoError(t, sut(t.Context(), TestCommand{}))
+	})
+
+	t.Run("panic without burst", func(t *testing.T) {
+		assert.Panic(t, func() {
+			commands.RateLimit(1, 0)
+		})
+	})
+
+	t.Run("panic with queue", func(t *testing.T) {
+		assert.Panic(t, func() {
+			commands.RateLimit(1, 1, commands.LimitQueue(1))
+		})
+	})
+
+	t.Run("fail with retry after when exhausted", func(t *testing.T) {
+		// arrange
+		var (
+			now, _ = newClock()
+			sut    = commands.RateLimit(2, 1, commands.LimitClock(now)).Intercept(next)
+		)
+
+		// act
+		first := sut(t.Context(), TestCommand{})
+		err := sut(t.Context(), TestCommand{})
+
+		// assert
+		assert.NoError(t, first)
+		assert.Truef(t, errors.Is(err, commands.ErrRateLimited), "expected ErrRateLimited, got %v", err)
+		var limitErr *commands.RateLimitError
+		if assert.Truef(t, errors.As(err, &limitErr), "expected RateLimitError") {
+			assert.Equal(t, "TestCommand", limitErr.Key)
+			assert.Equal(t, 500*time.Millisecond, limitErr.RetryAfter)
+		}
+	})
+
+	t.Run("refill tokens over time", func(t *testing.T) {
+		// arrange
+		var (
+			now, advance = newClock()
+			sut          = commands.RateLimit(10, 1, commands.LimitClock(now)).Intercept(next)
+		)
+
+		// act
+		first := sut(t.Context(), TestCommand{})
+		limited := sut(t.Context(), TestCommand{})
+		advance(100 * time.Millisecond)
+		refilled := sut(t.Context(), TestCommand{})
+
+		// assert
+		assert.NoError(t, first)
+		assert.Error(t, limited)
+		assert.NoError(t, refilled)
+	})
+
+	t.Run("limit by separate keys", func(t *testing.T) {
+		// arrange
+		var (
+			now, _ = newClock()
+			sut    = commands.RateLimit(1, 1, commands.LimitClock(now), commands.LimitKey(func(ctx context.Context, command commands.Command) string {
+				return command.(TestCommand).Value
+			})).Intercept(next)
+		)
+
+		// act & assert
+		assert.NoError(t, sut(t.Context(), TestCommand{Value: "tenant-a"}))
+		assert.NoError(t, sut(t.Context(), TestCommand{Value: "tenant-b"}))
+		assert.Error(t, sut(t.Context(), TestCommand{Value: "tenant-a"}))
+	})
+
+	t.Run("limit by entity type", func(t *testing.T) {
+		// arrange
+		var (
+			now, _     = newClock()
+			store      = &StoreMock{}
+			dispatcher = commands.New(store, commands.WithMiddlewares(
+				commands.RateLimit(1, 1, commands.LimitClock(now), commands.LimitKey(commands.EntityTypeKey)),
+			))
+		)
+
+		store.OpenFunc = func(ctx context.Context, entityType string, entityID string) es.Stream {
+			return &StreamMock{
+				ProjectFunc: func(handler es.Handler) error {
+					return nil
+				},
+				CloseFunc: func() error {
+					return nil
+				},
+			}
+		}
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		})
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd *TestPointerCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		})
+
+		// act
+		first := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{})
+		err := dispatcher.Dispatch(t.Context(), "account-1", &TestPointerCommand{})
+
+		// assert
+		assert.NoError(t, first)
+		var limitErr *commands.RateLimitError
+		if assert.Truef(t, errors.As(err, &limitErr), "expected RateLimitError, got %v", err) {
+			assert.Equal(t, "account", limitErr.Key)
+		}
+	})
+}
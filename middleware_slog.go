@@ -2,8 +2,14 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,7 +17,82 @@ func DefaultSlog() Middleware {
 	return SLogMiddleware(slog.Default())
 }
 
-func SLogMiddleware(logger *slog.Logger) MiddlewareFunc {
+type SLogOption func(cfg *slogConfig)
+
+// SLogSuccessLevel sets the level used when a command succeeds. Defaults to slog.LevelInfo.
+func SLogSuccessLevel(level slog.Leveler) SLogOption {
+	return func(cfg *slogConfig) {
+		cfg.successLevel = level
+	}
+}
+
+// SLogErrorLevel sets the level used when a command fails. Defaults to slog.LevelError.
+func SLogErrorLevel(level slog.Leveler) SLogOption {
+	return func(cfg *slogConfig) {
+		cfg.errorLevel = level
+	}
+}
+
+// SLogCommandFields adds the fields of the command to the log record.
+// Commands implementing slog.LogValuer control their own representation, otherwise
+// exported struct fields are logged using the `log` tag:
+//
+//	Name     string `log:"name"`
+//	Password string `log:",redact"`
+//	Internal string `log:"-"`
+func SLogCommandFields() SLogOption {
+	return func(cfg *slogConfig) {
+		cfg.commandFields = true
+	}
+}
+
+// SLogRedact replaces the value of the given command fields with a placeholder.
+// Fields are matched on either the Go field name or the name from the `log` tag.
+func SLogRedact(fields ...string) SLogOption {
+	return func(cfg *slogConfig) {
+		for _, field := range fields {
+			cfg.redact[field] = struct{}{}
+		}
+	}
+}
+
+// SLogSampleSuccess logs only a fraction of the successful commands, given by rate between 0 and 1.
+// Failed commands are always logged.
+func SLogSampleSuccess(rate float64) SLogOption {
+	return func(cfg *slogConfig) {
+		cfg.sampleRate = rate
+	}
+}
+
+type slogConfig struct {
+	successLevel  slog.Leveler
+	errorLevel    slog.Leveler
+	commandFields bool
+	redact        map[string]struct{}
+	sampleRate    float64
+}
+
+func newSLogConfig(opts ...SLogOption) *slogConfig {
+	cfg := &slogConfig{
+		successLevel: slog.LevelInfo,
+		errorLevel:   slog.LevelError,
+		redact:       make(map[string]struct{}),
+		sampleRate:   1,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+func (cfg *slogConfig) sample() bool {
+	return cfg.sampleRate >= 1 || rand.Float64() < cfg.sampleRate
+}
+
+func SLogMiddleware(logger *slog.Logger, opts ...SLogOption) MiddlewareFunc {
+	var cfg = newSLogConfig(opts...)
 	return func(next func(ctx context.Context, command Command) error) func(ctx context.Context, command Command) error {
 		return func(ctx context.Context, command Command) error {
 			start := time.Now()
@@ -20,18 +101,209 @@ func SLogMiddleware(logger *slog.Logger) MiddlewareFunc {
 				duration = time.Since(start)
 			)
 
-			log := logger.WithGroup("commands").With(
+			if err == nil && !cfg.sample() {
+				return nil
+			}
+
+			var attrs = []any{
 				"duration", duration.Milliseconds(),
 				"name", command.CommandName(),
-			)
+			}
+			if exec, ok := executionFrom(ctx); ok {
+				attrs = append(attrs,
+					"entity_type", exec.entityType,
+					"entity_id", exec.entityID,
+					"events", len(exec.events),
+				)
+			}
+			if cfg.commandFields {
+				attrs = append(attrs, slog.Any("command", commandLogValue(command, cfg.redact)))
+			}
+
+			log := logger.WithGroup("commands").With(attrs...)
 			if err != nil {
-				log.ErrorContext(ctx, fmt.Sprintf("[commands] %q executed in %s: %s", command.CommandName(), duration, err))
+				var errAttrs = []any{"error_kind", errorKind(err)}
+				if exec, ok := executionFrom(ctx); ok && errors.Is(err, context.DeadlineExceeded) {
+					errAttrs = append(errAttrs, "phase", exec.phase)
+				}
+
+				log.Log(ctx, cfg.errorLevel.Level(), fmt.Sprintf("[commands] %q executed in %s: %s", command.CommandName(), duration, err), errAttrs...)
 				return err
 			}
 
-			log.InfoContext(ctx, fmt.Sprintf("[commands] %q executed in %s", command.CommandName(), duration))
+			log.Log(ctx, cfg.successLevel.Level(), fmt.Sprintf("[commands] %q executed in %s", command.CommandName(), duration))
 
 			return nil
 		}
 	}
 }
+
+func errorKind(err error) string {
+	var kinder interface{ ErrorKind() string }
+	switch {
+	case errors.As(err, &kinder):
+		return kinder.ErrorKind()
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+
+	for {
+		inner := errors.Unwrap(err)
+		if inner == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = inner
+	}
+}
+
+const redacted = "[REDACTED]"
+
+// maxLogDepth bounds the nesting of command fields, guarding against self-referencing values.
+const maxLogDepth = 8
+
+func commandLogValue(command Command, redact map[string]struct{}) slog.Value {
+	if valuer, ok := command.(slog.LogValuer); ok {
+		return valuer.LogValue()
+	}
+
+	return reflectLogValue(reflect.ValueOf(command), redact, 0)
+}
+
+func reflectLogValue(v reflect.Value, redact map[string]struct{}, depth int) slog.Value {
+	if depth > maxLogDepth {
+		return slog.StringValue("[MAX DEPTH]")
+	}
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return slog.AnyValue(nil)
+		}
+		v = v.Elem()
+	}
+
+	if !v.CanInterface() {
+		return slog.StringValue(v.Type().String())
+	}
+
+	if valuer, ok := v.Interface().(slog.LogValuer); ok {
+		return valuer.LogValue()
+	}
+
+	// collections of values that may be redacted are logged element by element.
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if !isScalar(v.Type().Elem()) {
+			return sliceLogValue(v, redact, depth)
+		}
+	case reflect.Map:
+		if !isScalar(v.Type().Elem()) {
+			return mapLogValue(v, redact, depth)
+		}
+	}
+
+	// structs with exported fields are logged field by field, so a String method cannot bypass redaction.
+	if v.Kind() != reflect.Struct || !hasExportedFields(v.Type()) {
+		if stringer, ok := v.Interface().(fmt.Stringer); ok {
+			return slog.StringValue(stringer.String())
+		}
+		return slog.AnyValue(v.Interface())
+	}
+
+	var (
+		typ   = v.Type()
+		attrs = make([]slog.Attr, 0, typ.NumField())
+	)
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, redactTag, skip := parseLogTag(field)
+		if skip {
+			continue
+		}
+
+		_, redactName := redact[field.Name]
+		_, redactTagName := redact[name]
+		if redactTag || redactName || redactTagName {
+			attrs = append(attrs, slog.String(name, redacted))
+			continue
+		}
+
+		attrs = append(attrs, slog.Attr{Key: name, Value: reflectLogValue(v.Field(i), redact, depth+1)})
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+func sliceLogValue(v reflect.Value, redact map[string]struct{}, depth int) slog.Value {
+	var attrs = make([]slog.Attr, 0, v.Len())
+	for i := range v.Len() {
+		attrs = append(attrs, slog.Attr{Key: strconv.Itoa(i), Value: reflectLogValue(v.Index(i), redact, depth+1)})
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+func mapLogValue(v reflect.Value, redact map[string]struct{}, depth int) slog.Value {
+	var attrs = make([]slog.Attr, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		attrs = append(attrs, slog.Attr{Key: fmt.Sprint(key.Interface()), Value: reflectLogValue(v.MapIndex(key), redact, depth+1)})
+	}
+
+	slices.SortFunc(attrs, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return slog.GroupValue(attrs...)
+}
+
+// isScalar reports whether values of the type have no fields that could be redacted.
+func isScalar(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	default:
+		return false
+	}
+}
+
+func hasExportedFields(typ reflect.Type) bool {
+	for i := range typ.NumField() {
+		if typ.Field(i).IsExported() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseLogTag(field reflect.StructField) (name string, redact bool, skip bool) {
+	tag, ok := field.Tag.Lookup("log")
+	if !ok {
+		return field.Name, false, false
+	}
+
+	if tag == "-" {
+		return "", false, true
+	}
+
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+
+	for option := range strings.SplitSeq(options, ",") {
+		if option == "redact" {
+			redact = true
+		}
+	}
+
+	return name, redact, false
+}
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"log/slog"
 	"testing"
+	"time"
 
+	"github.com/kyuff/es"
 	commands "github.com/kyuff/es-commands"
 	"github.com/kyuff/es-commands/internal/assert"
 )
@@ -58,6 +60,270 @@ func TestSLogMiddleware(t *testing.T) {
 		t.Log(msg)
 	})
 
+	t.Run("log with configured levels", func(t *testing.T) {
+		// arrange
+		var (
+			buf        = &bytes.Buffer{}
+			logger     = slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			middleware = commands.SLogMiddleware(logger,
+				commands.SLogSuccessLevel(slog.LevelDebug),
+				commands.SLogErrorLevel(slog.LevelWarn),
+			)
+			success = middleware.Intercept(func(ctx context.Context, command commands.Command) error {
+				return nil
+			})
+			failure = middleware.Intercept(func(ctx context.Context, command commands.Command) error {
+				return errors.New("test error")
+			})
+		)
+
+		// act
+		_ = success(t.Context(), TestCommand{})
+		_ = failure(t.Context(), TestCommand{})
+
+		// assert
+		msg := buf.String()
+		assert.Match(t, "level=DEBUG", msg)
+		assert.Match(t, "level=WARN", msg)
+		assert.Match(t, "commands.error_kind=", msg)
+		t.Log(msg)
+	})
+
+	t.Run("log entity attributes", func(t *testing.T) {
+		// arrange
+		var (
+			buf        = &bytes.Buffer{}
+			logger     = slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{}))
+			store      = &StoreMock{}
+			stream     = &StreamMock{}
+			dispatcher = commands.New(store, commands.WithMiddlewares(commands.SLogMiddleware(logger)))
+		)
+
+		store.OpenFunc = func(ctx context.Context, entityType string, entityID string) es.Stream {
+			return stream
+		}
+		stream.ProjectFunc = func(handler es.Handler) error {
+			return nil
+		}
+		stream.WriteFunc = func(events ...es.Content) error {
+			return nil
+		}
+		stream.CloseFunc = func() error {
+			return nil
+		}
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return []es.Content{&ContentMock{}, &ContentMock{}}, nil
+		})
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{})
+
+		// assert
+		assert.NoError(t, err)
+		msg := buf.String()
+		assert.Match(t, "commands.entity_type=account", msg)
+		assert.Match(t, "commands.entity_id=account-1", msg)
+		assert.Match(t, "commands.events=2", msg)
+		t.Log(msg)
+	})
+
+	t.Run("log timeout phase", func(t *testing.T) {
+		// arrange
+		var (
+			buf        = &bytes.Buffer{}
+			logger     = slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{}))
+			store      = &StoreMock{}
+			stream     = &StreamMock{}
+			dispatcher = commands.New(store,
+				commands.WithMiddlewares(commands.SLogMiddleware(logger)),
+				commands.WithDefaultTimeout(time.Millisecond),
+			)
+		)
+
+		store.OpenFunc = func(ctx context.Context, entityType string, entityID string) es.Stream {
+			return stream
+		}
+		stream.ProjectFunc = func(handler es.Handler) error {
+			return nil
+		}
+		stream.CloseFunc = func() error {
+			return nil
+		}
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{})
+
+		// assert
+		assert.Error(t, err)
+		msg := buf.String()
+		assert.Match(t, "commands.error_kind=timeout", msg)
+		assert.Match(t, "commands.phase=execute", msg)
+		t.Log(msg)
+	})
+
+	t.Run("log command fields with redaction", func(t *testing.T) {
+		// arrange
+		var (
+			buf        = &bytes.Buffer{}
+			logger     = slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{}))
+			middleware = commands.SLogMiddleware(logger,
+				commands.SLogCommandFields(),
+				commands.SLogRedact("Email"),
+			)
+			sut = middleware.Intercept(func(ctx context.Context, command commands.Command) error {
+				return nil
+			})
+		)
+
+		// act
+		err := sut(t.Context(), TestTaggedCommand{
+			Owner:    "owner-1",
+			Email:    "owner@example.com",
+			Password: "secret",
+			Internal: "internal",
+		})
+
+		// assert
+		assert.NoError(t, err)
+		msg := buf.String()
+		assert.Match(t, "commands.command.owner=owner-1", msg)
+		assert.Match(t, "commands.command.Email=\\[REDACTED\\]", msg)
+		assert.Match(t, "commands.command.Password=\\[REDACTED\\]", msg)
+		assert.Truef(t, !bytes.Contains(buf.Bytes(), []byte("secret")), "expected password to be redacted")
+		assert.Truef(t, !bytes.Contains(buf.Bytes(), []byte("internal")), "expected internal to be skipped")
+		t.Log(msg)
+	})
+
+	t.Run("log command fields with redaction before stringer", func(t *testing.T) {
+		// arrange
+		var (
+			buf        = &bytes.Buffer{}
+			logger     = slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{}))
+			middleware = commands.SLogMiddleware(logger, commands.SLogCommandFields())
+			sut        = middleware.Intercept(func(ctx context.Context, command commands.Command) error {
+				return nil
+			})
+		)
+
+		// act
+		err := sut(t.Context(), TestStringerCommand{Owner: "owner-1", Password: "secret"})
+
+		// assert
+		assert.NoError(t, err)
+		msg := buf.String()
+		assert.Match(t, "commands.command.Owner=owner-1", msg)
+		assert.Match(t, "commands.command.Password=\\[REDACTED\\]", msg)
+		assert.Truef(t, !bytes.Contains(buf.Bytes(), []byte("secret")), "expected password to be redacted")
+		t.Log(msg)
+	})
+
+	t.Run("log command fields with redaction in collections", func(t *testing.T) {
+		// arrange
+		var (
+			buf        = &bytes.Buffer{}
+			logger     = slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{}))
+			middleware = commands.SLogMiddleware(logger,
+				commands.SLogCommandFields(),
+				commands.SLogRedact("Email"),
+			)
+			sut = middleware.Intercept(func(ctx context.Context, command commands.Command) error {
+				return nil
+			})
+		)
+
+		// act
+		err := sut(t.Context(), TestCollectionCommand{
+			Users: []TestTaggedCommand{{Owner: "owner-1", Email: "owner@example.com", Password: "hunter2"}},
+			ByKey: map[string]TestTaggedCommand{"key-1": {Owner: "owner-2", Password: "s3cret"}},
+			Tags:  []string{"tag-1"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		msg := buf.String()
+		assert.Match(t, "commands.command.Users.0.owner=owner-1", msg)
+		assert.Match(t, "commands.command.Users.0.Email=\\[REDACTED\\]", msg)
+		assert.Match(t, "commands.command.Users.0.Password=\\[REDACTED\\]", msg)
+		assert.Match(t, "commands.command.ByKey.key-1.owner=owner-2", msg)
+		assert.Match(t, "commands.command.ByKey.key-1.Password=\\[REDACTED\\]", msg)
+		assert.Match(t, "commands.command.Tags=\\[tag-1\\]", msg)
+		assert.Truef(t, !bytes.Contains(buf.Bytes(), []byte("hunter2")), "expected password in slice to be redacted")
+		assert.Truef(t, !bytes.Contains(buf.Bytes(), []byte("s3cret")), "expected password in map to be redacted")
+		assert.Truef(t, !bytes.Contains(buf.Bytes(), []byte("owner@example.com")), "expected email to be redacted")
+	})
+
+	t.Run("log self-referencing command fields", func(t *testing.T) {
+		// arrange
+		var (
+			buf        = &bytes.Buffer{}
+			logger     = slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{}))
+			middleware = commands.SLogMiddleware(logger, commands.SLogCommandFields())
+			sut        = middleware.Intercept(func(ctx context.Context, command commands.Command) error {
+				return nil
+			})
+			cmd = &TestStringerCommand{Owner: "owner-1"}
+		)
+		cmd.Next = cmd
+
+		// act
+		err := sut(t.Context(), cmd)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Match(t, "\\[MAX DEPTH\\]", buf.String())
+	})
+
+	t.Run("log command fields with log valuer", func(t *testing.T) {
+		// arrange
+		var (
+			buf        = &bytes.Buffer{}
+			logger     = slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{}))
+			middleware = commands.SLogMiddleware(logger, commands.SLogCommandFields())
+			sut        = middleware.Intercept(func(ctx context.Context, command commands.Command) error {
+				return nil
+			})
+		)
+
+		// act
+		err := sut(t.Context(), TestLogValuerCommand{Value: "value"})
+
+		// assert
+		assert.NoError(t, err)
+		msg := buf.String()
+		assert.Match(t, "commands.command=logged-value", msg)
+		t.Log(msg)
+	})
+
+	t.Run("sample successful commands", func(t *testing.T) {
+		// arrange
+		var (
+			buf        = &bytes.Buffer{}
+			logger     = slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{}))
+			middleware = commands.SLogMiddleware(logger, commands.SLogSampleSuccess(0))
+			success    = middleware.Intercept(func(ctx context.Context, command commands.Command) error {
+				return nil
+			})
+			failure = middleware.Intercept(func(ctx context.Context, command commands.Command) error {
+				return errors.New("test error")
+			})
+		)
+
+		// act
+		_ = success(t.Context(), TestCommand{})
+		_ = failure(t.Context(), TestCommand{})
+
+		// assert
+		msg := buf.String()
+		assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
+		assert.Match(t, "level=ERROR", msg)
+	})
+
 	t.Run("log default", func(t *testing.T) {
 		// act
 		got := commands.DefaultSlog()
@@ -486,3 +486,89 @@ func (mock *MiddlewareMock) InterceptCalls() []struct {
 	mock.lockIntercept.RUnlock()
 	return calls
 }
+
+// PolicyMock is a mock implementation of commands.Policy.
+//
+//	func TestSomethingThatUsesPolicy(t *testing.T) {
+//
+//		// make and configure a mocked commands.Policy
+//		mockedPolicy := &PolicyMock{
+//			AllowFunc: func(ctx context.Context, actor commands.Actor, commandName string, entityType string, entityID string) error {
+//				panic("mock out the Allow method")
+//			},
+//		}
+//
+//		// use mockedPolicy in code that requires commands.Policy
+//		// and then make assertions.
+//
+//	}
+type PolicyMock struct {
+	// AllowFunc mocks the Allow method.
+	AllowFunc func(ctx context.Context, actor commands.Actor, commandName string, entityType string, entityID string) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Allow holds details about calls to the Allow method.
+		Allow []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Actor is the actor argument value.
+			Actor commands.Actor
+			// CommandName is the commandName argument value.
+			CommandName string
+			// EntityType is the entityType argument value.
+			EntityType string
+			// EntityID is the entityID argument value.
+			EntityID string
+		}
+	}
+	lockAllow sync.RWMutex
+}
+
+// Allow calls AllowFunc.
+func (mock *PolicyMock) Allow(ctx context.Context, actor commands.Actor, commandName string, entityType string, entityID string) error {
+	if mock.AllowFunc == nil {
+		panic("PolicyMock.AllowFunc: method is nil but Policy.Allow was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		Actor       commands.Actor
+		CommandName string
+		EntityType  string
+		EntityID    string
+	}{
+		Ctx:         ctx,
+		Actor:       actor,
+		CommandName: commandName,
+		EntityType:  entityType,
+		EntityID:    entityID,
+	}
+	mock.lockAllow.Lock()
+	mock.calls.Allow = append(mock.calls.Allow, callInfo)
+	mock.lockAllow.Unlock()
+	return mock.AllowFunc(ctx, actor, commandName, entityType, entityID)
+}
+
+// AllowCalls gets all the calls that were made to Allow.
+// Check the length with:
+//
+//	len(mockedPolicy.AllowCalls())
+func (mock *PolicyMock) AllowCalls() []struct {
+	Ctx         context.Context
+	Actor       commands.Actor
+	CommandName string
+	EntityType  string
+	EntityID    string
+} {
+	var calls []struct {
+		Ctx         context.Context
+		Actor       commands.Actor
+		CommandName string
+		EntityType  string
+		EntityID    string
+	}
+	mock.lockAllow.RLock()
+	calls = mock.calls.Allow
+	mock.lockAllow.RUnlock()
+	return calls
+}
@@ -0,0 +1,199 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/kyuff/es"
+)
+
+var ErrCausationLoop = errors.New("causation loop")
+
+// CausationLoopError is returned when a chain of events and commands grows deeper than allowed.
+type CausationLoopError struct {
+	EventName   string
+	CommandName string
+	Depth       int
+}
+
+func (e *CausationLoopError) Error() string {
+	return fmt.Sprintf("command %s caused by event %s at depth %d", e.CommandName, e.EventName, e.Depth)
+}
+
+func (e *CausationLoopError) Unwrap() error {
+	return ErrCausationLoop
+}
+
+func (e *CausationLoopError) ErrorKind() string {
+	return "causation_loop"
+}
+
+// MetadataCausationDepth is the number of commands in the chain before the command.
+const MetadataCausationDepth = "causation_depth"
+
+// WithCausationDepths sets how many of the events written by commands dispatched from an
+// EventPolicy the Dispatcher remembers the causation depth of. Defaults to 10000.
+func WithCausationDepths(size int) Option {
+	return func(cfg *config) {
+		cfg.causationDepths = size
+	}
+}
+
+type OnEventOption func(cfg *onEventConfig)
+
+// OnEventMaxDepth sets how deep a chain of commands can be before it is considered a loop. Defaults to 10.
+func OnEventMaxDepth(depth int) OnEventOption {
+	return func(cfg *onEventConfig) {
+		cfg.maxDepth = depth
+	}
+}
+
+type onEventConfig struct {
+	maxDepth int
+}
+
+// OnEvent creates an es.Handler that dispatches the command returned by fn for events of type E.
+// A nil command means the event is ignored.
+//
+// The depth of the causation chain is tracked by the Dispatcher for the events written by the
+// commands, and is carried in the Metadata of the commands. A command that would make the chain
+// deeper than the max depth fails with a CausationLoopError. With TenantStores, events are
+// handled with ContextWithTenant of their Store, as the tenants share entity types.
+//
+// Events handled outside the context of the command that wrote them, such as from a
+// subscription, only have their depth in the memory of the Dispatcher, bounded by
+// WithCausationDepths. Loops that span processes, restarts or more events than it
+// remembers are not detected.
+func OnEvent[E es.Content](dispatcher *Dispatcher, fn func(ctx context.Context, event E) (string, Command), opts ...OnEventOption) *EventPolicy[E] {
+	var cfg = &onEventConfig{
+		maxDepth: 10,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return &EventPolicy[E]{
+		dispatcher: dispatcher,
+		fn:         fn,
+		cfg:        cfg,
+	}
+}
+
+type EventPolicy[E es.Content] struct {
+	dispatcher *Dispatcher
+	fn         func(ctx context.Context, event E) (string, Command)
+	cfg        *onEventConfig
+}
+
+func (p *EventPolicy[E]) Handle(ctx context.Context, event es.Event) error {
+	content, ok := event.Content.(E)
+	if !ok {
+		return nil
+	}
+
+	entityID, cmd := p.fn(ctx, content)
+	if cmd == nil {
+		return nil
+	}
+
+	depth := max(metadataDepth(ctx), p.dispatcher.causation.depth(p.dispatcher.cfg.tenants.store(ctx), event)) + 1
+	if depth > p.cfg.maxDepth {
+		return &CausationLoopError{
+			EventName:   event.Content.EventName(),
+			CommandName: cmd.CommandName(),
+			Depth:       depth,
+		}
+	}
+
+	ctx = withMetadata(ctx,
+		MetadataCausationID, event.StoreEventID,
+		MetadataCausationDepth, strconv.Itoa(depth),
+	)
+	ctx = context.WithValue(ctx, causationKey{}, causation{depths: p.dispatcher.causation, depth: depth})
+
+	return p.dispatcher.Dispatch(ctx, entityID, cmd)
+}
+
+func metadataDepth(ctx context.Context) int {
+	depth, err := strconv.Atoi(MetadataFromContext(ctx)[MetadataCausationDepth])
+	if err != nil {
+		return 0
+	}
+
+	return depth
+}
+
+type causationKey struct{}
+
+// causation is the depth of the command being dispatched, passed on to the events it writes.
+type causation struct {
+	depths *causationDepths
+	depth  int
+}
+
+// recordCausation returns a func that records the causation of events once they are written
+// to the stream at its current position. The store is the tenant whose Store has the stream, if any.
+func recordCausation(ctx context.Context, store, entityType, entityID string, stream es.Stream) func(events int) {
+	c, ok := ctx.Value(causationKey{}).(causation)
+	if !ok {
+		return func(events int) {}
+	}
+
+	position := stream.Position()
+	return func(events int) {
+		c.depths.record(store, entityType, entityID, position, events, c.depth)
+	}
+}
+
+type eventKey struct {
+	store       string
+	entityType  string
+	entityID    string
+	eventNumber int64
+}
+
+// causationDepths remembers the depth of the events written by commands dispatched from
+// an EventPolicy, so it is known when the events are handled outside the context of the command.
+// The depth is kept here because es.Event has no metadata to carry it in.
+type causationDepths struct {
+	mux    sync.Mutex
+	size   int
+	depths map[eventKey]int
+	keys   []eventKey
+}
+
+func newCausationDepths(size int) *causationDepths {
+	return &causationDepths{
+		size:   size,
+		depths: make(map[eventKey]int),
+	}
+}
+
+func (c *causationDepths) depth(store string, event es.Event) int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.depths[eventKey{store, event.EntityType, event.EntityID, event.EventNumber}]
+}
+
+func (c *causationDepths) record(store, entityType, entityID string, position int64, events int, depth int) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for i := range int64(events) {
+		key := eventKey{store, entityType, entityID, position + i + 1}
+		if _, ok := c.depths[key]; !ok {
+			c.keys = append(c.keys, key)
+		}
+		c.depths[key] = depth
+	}
+
+	// forget the oldest events
+	for len(c.keys) > c.size {
+		delete(c.depths, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+}
@@ -0,0 +1,277 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es/storage/inmemory"
+)
+
+func TestOnEvent(t *testing.T) {
+	var (
+		newStore = func() *StoreMock {
+			return &StoreMock{
+				OpenFunc: func(ctx context.Context, entityType string, entityID string) es.Stream {
+					return &StreamMock{
+						ProjectFunc: func(handler es.Handler) error {
+							return nil
+						},
+						WriteFunc: func(events ...es.Content) error {
+							return nil
+						},
+						CloseFunc: func() error {
+							return nil
+						},
+					}
+				},
+			}
+		}
+		event = es.Event{
+			EntityID:     "order-1",
+			EntityType:   "order",
+			EventNumber:  1,
+			Content:      TestEvent{Value: "placed"},
+			StoreEventID: "event-1",
+		}
+	)
+
+	t.Run("dispatch command for event", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = commands.NewDispatcher(newStore())
+			received   []string
+			metadata   commands.Metadata
+			sut        = commands.OnEvent(dispatcher, func(ctx context.Context, event TestEvent) (string, commands.Command) {
+				return "account-1", TestCommand{Value: event.Value}
+			})
+		)
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			received = append(received, cmd.Value)
+			metadata = commands.MetadataFromContext(ctx)
+			return nil, nil
+		})
+
+		// act
+		err := sut.Handle(t.Context(), event)
+
+		// assert
+		assert.NoError(t, err)
+		assert.EqualSlice(t, []string{"placed"}, received)
+		assert.Equal(t, "event-1", metadata[commands.MetadataCausationID])
+		assert.Equal(t, "1", metadata[commands.MetadataCausationDepth])
+	})
+
+	t.Run("ignore other events", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = commands.NewDispatcher(newStore())
+			called     = false
+			sut        = commands.OnEvent(dispatcher, func(ctx context.Context, event TestEvent) (string, commands.Command) {
+				called = true
+				return "account-1", TestCommand{}
+			})
+			other = event
+		)
+		other.Content = &ContentMock{EventNameFunc: func() string {
+			return "OtherEvent"
+		}}
+
+		// act
+		err := sut.Handle(t.Context(), other)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Truef(t, !called, "expected the policy not to be called")
+	})
+
+	t.Run("ignore nil command", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = commands.NewDispatcher(newStore())
+			sut        = commands.OnEvent(dispatcher, func(ctx context.Context, event TestEvent) (string, commands.Command) {
+				return "", nil
+			})
+		)
+
+		// act
+		err := sut.Handle(t.Context(), event)
+
+		// assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail dispatching unregistered command", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = commands.NewDispatcher(newStore())
+			sut        = commands.OnEvent(dispatcher, func(ctx context.Context, event TestEvent) (string, commands.Command) {
+				return "account-1", TestCommand{}
+			})
+		)
+
+		// act
+		err := sut.Handle(t.Context(), event)
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNotRegistered), "expected ErrNotRegistered, got %v", err)
+	})
+
+	t.Run("fail beyond max depth in metadata", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = commands.NewDispatcher(newStore())
+			sut        = commands.OnEvent(dispatcher, func(ctx context.Context, event TestEvent) (string, commands.Command) {
+				return "account-1", TestCommand{}
+			}, commands.OnEventMaxDepth(3))
+			ctx = commands.ContextWithMetadata(t.Context(), commands.Metadata{commands.MetadataCausationDepth: "3"})
+		)
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		})
+
+		// act
+		err := sut.Handle(ctx, event)
+
+		// assert
+		var loopErr *commands.CausationLoopError
+		assert.Truef(t, errors.As(err, &loopErr), "expected CausationLoopError, got %v", err)
+		assert.Truef(t, errors.Is(err, commands.ErrCausationLoop), "expected ErrCausationLoop")
+		assert.Equal(t, 4, loopErr.Depth)
+		assert.Equal(t, "TestEvent", loopErr.EventName)
+		assert.Equal(t, "TestCommand", loopErr.CommandName)
+	})
+
+	t.Run("ignore depth of events that failed to be written", func(t *testing.T) {
+		// arrange
+		var (
+			writeErr   = errors.New("write-error")
+			store      = &StoreMock{}
+			dispatcher = commands.NewDispatcher(store)
+			sut        = commands.OnEvent(dispatcher, func(ctx context.Context, event TestEvent) (string, commands.Command) {
+				return "order-1", TestCommand{Value: event.Value}
+			}, commands.OnEventMaxDepth(1))
+			next = event
+		)
+		store.OpenFunc = func(ctx context.Context, entityType string, entityID string) es.Stream {
+			return &StreamMock{
+				ProjectFunc: func(handler es.Handler) error {
+					return nil
+				},
+				WriteFunc: func(events ...es.Content) error {
+					return writeErr
+				},
+				PositionFunc: func() int64 {
+					return 1
+				},
+				CloseFunc: func() error {
+					return nil
+				},
+			}
+		}
+		_ = commands.RegisterFunc(dispatcher, "order", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return []es.Content{TestEvent{Value: cmd.Value}}, nil
+		})
+		assert.Truef(t, errors.Is(sut.Handle(t.Context(), event), writeErr), "expected write error")
+		next.EventNumber = 2
+
+		// act
+		err := sut.Handle(t.Context(), next)
+
+		// assert
+		assert.Truef(t, errors.Is(err, writeErr), "expected write error, got %v", err)
+	})
+
+	t.Run("forget depth of the oldest events", func(t *testing.T) {
+		// arrange
+		var (
+			store = &StoreMock{
+				OpenFunc: func(ctx context.Context, entityType string, entityID string) es.Stream {
+					return &StreamMock{
+						ProjectFunc: func(handler es.Handler) error {
+							return nil
+						},
+						WriteFunc: func(events ...es.Content) error {
+							return nil
+						},
+						PositionFunc: func() int64 {
+							return 1
+						},
+						CloseFunc: func() error {
+							return nil
+						},
+					}
+				},
+			}
+			dispatcher = commands.New(store, commands.WithCausationDepths(1))
+			sut        = commands.OnEvent(dispatcher, func(ctx context.Context, event TestEvent) (string, commands.Command) {
+				return "order-1", TestCommand{Value: event.Value}
+			}, commands.OnEventMaxDepth(1))
+			forgotten = event
+			kept      = event
+		)
+		_ = commands.RegisterFunc(dispatcher, "order", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return []es.Content{TestEvent{Value: "first"}, TestEvent{Value: "second"}}, nil
+		})
+		assert.NoError(t, sut.Handle(t.Context(), event))
+		forgotten.EventNumber = 2
+		kept.EventNumber = 3
+
+		// act
+		errKept := sut.Handle(t.Context(), kept)
+		errForgotten := sut.Handle(t.Context(), forgotten)
+
+		// assert
+		assert.NoError(t, errForgotten)
+		assert.Truef(t, errors.Is(errKept, commands.ErrCausationLoop), "expected ErrCausationLoop, got %v", errKept)
+	})
+
+	t.Run("detect loop through subscription", func(t *testing.T) {
+		// arrange
+		var (
+			storage     = inmemory.New()
+			store       = es.NewStore(storage)
+			dispatcher  = commands.NewDispatcher(store)
+			executed    atomic.Int32
+			errs        = make(chan error, 1)
+			ctx, cancel = context.WithCancel(t.Context())
+			sut         = commands.OnEvent(dispatcher, func(ctx context.Context, event TestEvent) (string, commands.Command) {
+				return "account-1", TestCommand{Value: event.Value}
+			}, commands.OnEventMaxDepth(3))
+		)
+		defer cancel()
+		assert.NoError(t, storage.Register("account", TestEvent{}))
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *TestProcessState) ([]es.Content, error) {
+			executed.Add(1)
+			return []es.Content{TestEvent{Value: cmd.Value}}, nil
+		})
+		assert.NoError(t, store.Subscribe(ctx, "account", "policy", es.HandlerFunc(func(ctx context.Context, event es.Event) error {
+			err := sut.Handle(ctx, event)
+			if err != nil {
+				errs <- err
+			}
+			return err
+		})))
+		go func() {
+			_ = store.Start(ctx)
+		}()
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{Value: "value"})
+
+		// assert
+		assert.NoError(t, err)
+		select {
+		case err = <-errs:
+		case <-time.After(time.Second):
+			t.Fatal("expected the loop to be detected")
+		}
+		assert.Truef(t, errors.Is(err, commands.ErrCausationLoop), "expected ErrCausationLoop, got %v", err)
+		assert.Equal(t, int32(4), executed.Load())
+	})
+}
@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kyuff/es"
+)
+
+// ProcessError is returned by a ProcessManager when a step of a Reaction failed.
+// Err holds the failure together with any errors from dispatching the compensations.
+type ProcessError struct {
+	ProcessType string
+	ProcessID   string
+	CommandName string
+	Err         error
+}
+
+func (e *ProcessError) Error() string {
+	return fmt.Sprintf("process %s %q: command %s: %s", e.ProcessType, e.ProcessID, e.CommandName, e.Err)
+}
+
+func (e *ProcessError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ProcessError) ErrorKind() string {
+	return "process"
+}
+
+// Step is a command dispatched by a process.
+type Step struct {
+	EntityID string
+	Command  Command
+	// Compensation is dispatched to the same entity if a later step of the Reaction fails.
+	Compensation Command
+}
+
+// Timeout is a command scheduled by a process. The command should check whether the
+// process is still waiting for it, as it is dispatched no matter how the process went on.
+type Timeout struct {
+	After    time.Duration
+	EntityID string
+	Command  Command
+}
+
+// Reaction of a process to an event.
+type Reaction struct {
+	// Events are written to the stream of the process when all steps succeeded and the timeouts
+	// are scheduled. If anything fails, they are not written, so the event is reacted to again
+	// when it is delivered again.
+	Events []es.Content
+	// Steps are dispatched in order.
+	Steps []Step
+	// Timeouts are scheduled with the Scheduler of the Dispatcher when all steps succeeded.
+	Timeouts []Timeout
+}
+
+type ProcessFunc[S State] func(ctx context.Context, event es.Event, state S) (Reaction, error)
+
+type ProcessOption func(cfg *processConfig)
+
+func ProcessClock(now func() time.Time) ProcessOption {
+	return func(cfg *processConfig) {
+		cfg.now = now
+	}
+}
+
+type processConfig struct {
+	now func() time.Time
+}
+
+// NewProcessManager creates a ProcessManager for processes of the given type.
+// The correlate func finds the ID of the process an event belongs to, if any.
+func NewProcessManager[S State](dispatcher *Dispatcher, processType string, correlate func(event es.Event) (string, bool), react ProcessFunc[S], opts ...ProcessOption) *ProcessManager[S] {
+	var cfg = &processConfig{
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return &ProcessManager[S]{
+		dispatcher:  dispatcher,
+		processType: processType,
+		correlate:   correlate,
+		react:       react,
+		newState:    newInstance[S](),
+		cfg:         cfg,
+	}
+}
+
+// ProcessManager is an es.Handler that reacts to events with commands. Each process keeps
+// its state as an entity in the Store of the Dispatcher. Commands are dispatched with the
+// process ID as correlation ID and the ID of the event as causation ID in their Metadata.
+//
+// Events may be delivered more than once, so a ProcessFunc should use its state to ignore
+// events it has already reacted to. Steps are dispatched again when the events of a Reaction
+// could not be written, so commands of steps should be idempotent.
+type ProcessManager[S State] struct {
+	dispatcher  *Dispatcher
+	processType string
+	correlate   func(event es.Event) (string, bool)
+	react       ProcessFunc[S]
+	newState    func() S
+	cfg         *processConfig
+}
+
+func (pm *ProcessManager[S]) Handle(ctx context.Context, event es.Event) error {
+	processID, ok := pm.correlate(event)
+	if !ok {
+		return nil
+	}
+
+	stream := pm.dispatcher.store.Open(ctx, pm.processType, processID)
+	defer func() {
+		_ = stream.Close()
+	}()
+
+	reaction, err := pm.reaction(ctx, stream, event)
+	if err != nil {
+		return fmt.Errorf("process %s %q: %w", pm.processType, processID, err)
+	}
+
+	correlationID := MetadataFromContext(ctx)[MetadataCorrelationID]
+	if correlationID == "" {
+		correlationID = processID
+	}
+	ctx = withMetadata(ctx,
+		MetadataCorrelationID, correlationID,
+		MetadataCausationID, event.StoreEventID,
+	)
+
+	for i, step := range reaction.Steps {
+		err = pm.dispatcher.Dispatch(ctx, step.EntityID, step.Command)
+		if err != nil {
+			return &ProcessError{
+				ProcessType: pm.processType,
+				ProcessID:   processID,
+				CommandName: step.Command.CommandName(),
+				Err:         errors.Join(err, pm.compensate(ctx, reaction.Steps[:i])),
+			}
+		}
+	}
+
+	for _, timeout := range reaction.Timeouts {
+		_, err = pm.dispatcher.Schedule(ctx, pm.cfg.now().Add(timeout.After), timeout.EntityID, timeout.Command)
+		if err != nil {
+			return fmt.Errorf("process %s %q: %w", pm.processType, processID, err)
+		}
+	}
+
+	if len(reaction.Events) > 0 {
+		err = stream.Write(reaction.Events...)
+		if err != nil {
+			return fmt.Errorf("process %s %q: %w", pm.processType, processID, err)
+		}
+	}
+
+	return nil
+}
+
+// reaction projects the state of the process from the stream, and reacts to the event.
+func (pm *ProcessManager[S]) reaction(ctx context.Context, stream es.Stream, event es.Event) (Reaction, error) {
+	var state = pm.newState()
+	err := stream.Project(state)
+	if err != nil {
+		return Reaction{}, err
+	}
+
+	return pm.react(ctx, event, state)
+}
+
+// compensate the steps in reverse order.
+func (pm *ProcessManager[S]) compensate(ctx context.Context, steps []Step) error {
+	var errs []error
+	for _, step := range slices.Backward(steps) {
+		if step.Compensation == nil {
+			continue
+		}
+
+		err := pm.dispatcher.Dispatch(ctx, step.EntityID, step.Compensation)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("compensate %s: %w", step.Command.CommandName(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
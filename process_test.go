@@ -0,0 +1,259 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es/storage/inmemory"
+)
+
+func TestProcessManager(t *testing.T) {
+	var (
+		newDispatcher = func(t *testing.T, opts ...commands.Option) *commands.Dispatcher {
+			var storage = inmemory.New()
+			assert.NoError(t, storage.Register("process", TestEvent{}))
+			return commands.New(es.NewStore(storage), opts...)
+		}
+		correlate = func(event es.Event) (string, bool) {
+			return event.EntityID, event.EntityType == "order"
+		}
+		event = func(entityType, value string) es.Event {
+			return es.Event{
+				EntityID:     "order-1",
+				EntityType:   entityType,
+				Content:      TestEvent{Value: value},
+				StoreEventID: "event-" + value,
+			}
+		}
+		record = func(received *[]string) func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+				*received = append(*received, cmd.Value)
+				if cmd.Value == "fail" {
+					return nil, errors.New("executor-error")
+				}
+				return nil, nil
+			}
+		}
+	)
+
+	t.Run("ignore uncorrelated events", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = newDispatcher(t)
+			called     = false
+			sut        = commands.NewProcessManager(dispatcher, "process", correlate, func(ctx context.Context, event es.Event, state *TestProcessState) (commands.Reaction, error) {
+				called = true
+				return commands.Reaction{}, nil
+			})
+		)
+
+		// act
+		err := sut.Handle(t.Context(), event("payment", "placed"))
+
+		// assert
+		assert.NoError(t, err)
+		assert.Truef(t, !called, "expected process not to react")
+	})
+
+	t.Run("dispatch steps with correlation", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = newDispatcher(t)
+			received   []commands.Metadata
+			sut        = commands.NewProcessManager(dispatcher, "process", correlate, func(ctx context.Context, event es.Event, state *TestProcessState) (commands.Reaction, error) {
+				return commands.Reaction{
+					Steps: []commands.Step{{EntityID: "stock-1", Command: TestCommand{Value: "reserve"}}},
+				}, nil
+			})
+		)
+		_ = commands.RegisterFunc(dispatcher, "stock", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			received = append(received, commands.MetadataFromContext(ctx))
+			return nil, nil
+		})
+
+		// act
+		err := sut.Handle(commands.ContextWithMetadata(t.Context(), commands.Metadata{"tenant": "tenant-1"}), event("order", "placed"))
+
+		// assert
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(received)) {
+			assert.Equal(t, "order-1", received[0][commands.MetadataCorrelationID])
+			assert.Equal(t, "event-placed", received[0][commands.MetadataCausationID])
+			assert.Equal(t, "tenant-1", received[0]["tenant"])
+		}
+	})
+
+	t.Run("keep existing correlation", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = newDispatcher(t)
+			received   commands.Metadata
+			sut        = commands.NewProcessManager(dispatcher, "process", correlate, func(ctx context.Context, event es.Event, state *TestProcessState) (commands.Reaction, error) {
+				return commands.Reaction{
+					Steps: []commands.Step{{EntityID: "stock-1", Command: TestCommand{Value: "reserve"}}},
+				}, nil
+			})
+		)
+		_ = commands.RegisterFunc(dispatcher, "stock", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			received = commands.MetadataFromContext(ctx)
+			return nil, nil
+		})
+
+		// act
+		err := sut.Handle(commands.ContextWithMetadata(t.Context(), commands.Metadata{commands.MetadataCorrelationID: "request-1"}), event("order", "placed"))
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "request-1", received[commands.MetadataCorrelationID])
+	})
+
+	t.Run("keep process state across events", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = newDispatcher(t)
+			states     [][]string
+			sut        = commands.NewProcessManager(dispatcher, "process", correlate, func(ctx context.Context, event es.Event, state *TestProcessState) (commands.Reaction, error) {
+				states = append(states, state.Values)
+				return commands.Reaction{
+					Events: []es.Content{event.Content},
+				}, nil
+			})
+		)
+
+		// act
+		assert.NoError(t, sut.Handle(t.Context(), event("order", "placed")))
+		assert.NoError(t, sut.Handle(t.Context(), event("order", "paid")))
+
+		// assert
+		if assert.Equal(t, 2, len(states)) {
+			assert.EqualSlice(t, nil, states[0])
+			assert.EqualSlice(t, []string{"placed"}, states[1])
+		}
+	})
+
+	t.Run("compensate earlier steps on failure", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher  = newDispatcher(t)
+			received    []string
+			compensated []string
+			sut         = commands.NewProcessManager(dispatcher, "process", correlate, func(ctx context.Context, event es.Event, state *TestProcessState) (commands.Reaction, error) {
+				return commands.Reaction{
+					Steps: []commands.Step{
+						{EntityID: "stock-1", Command: TestCommand{Value: "first"}, Compensation: TestCompensateCommand{Value: "first"}},
+						{EntityID: "stock-2", Command: TestCommand{Value: "second"}},
+						{EntityID: "stock-3", Command: TestCommand{Value: "third"}, Compensation: TestCompensateCommand{Value: "third"}},
+						{EntityID: "stock-4", Command: TestCommand{Value: "fail"}, Compensation: TestCompensateCommand{Value: "fail"}},
+						{EntityID: "stock-5", Command: TestCommand{Value: "never"}},
+					},
+				}, nil
+			})
+		)
+		_ = commands.RegisterFunc(dispatcher, "stock", record(&received))
+		_ = commands.RegisterFunc(dispatcher, "stock", func(ctx context.Context, cmd TestCompensateCommand, state *StateMock) ([]es.Content, error) {
+			compensated = append(compensated, cmd.Value)
+			return nil, nil
+		})
+
+		// act
+		err := sut.Handle(t.Context(), event("order", "placed"))
+
+		// assert
+		var processErr *commands.ProcessError
+		assert.Truef(t, errors.As(err, &processErr), "expected ProcessError, got %v", err)
+		assert.Equal(t, "process", processErr.ProcessType)
+		assert.Equal(t, "order-1", processErr.ProcessID)
+		assert.Equal(t, "TestCommand", processErr.CommandName)
+		assert.EqualSlice(t, []string{"first", "second", "third", "fail"}, received)
+		assert.EqualSlice(t, []string{"third", "first"}, compensated)
+	})
+
+	t.Run("keep events unwritten when a step fails", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = newDispatcher(t)
+			received   []string
+			states     [][]string
+			sut        = commands.NewProcessManager(dispatcher, "process", correlate, func(ctx context.Context, event es.Event, state *TestProcessState) (commands.Reaction, error) {
+				states = append(states, state.Values)
+				return commands.Reaction{
+					Events: []es.Content{event.Content},
+					Steps:  []commands.Step{{EntityID: "stock-1", Command: TestCommand{Value: "fail"}}},
+				}, nil
+			})
+		)
+		_ = commands.RegisterFunc(dispatcher, "stock", record(&received))
+
+		// act
+		first := sut.Handle(t.Context(), event("order", "placed"))
+		second := sut.Handle(t.Context(), event("order", "placed"))
+
+		// assert
+		assert.Error(t, first)
+		assert.Error(t, second)
+		assert.EqualSlice(t, []string{"fail", "fail"}, received)
+		if assert.Equal(t, 2, len(states)) {
+			assert.EqualSlice(t, nil, states[0])
+			assert.EqualSlice(t, nil, states[1])
+		}
+	})
+
+	t.Run("fail without dispatching when reaction fails", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = newDispatcher(t)
+			received   []string
+			reactErr   = errors.New("react-error")
+			sut        = commands.NewProcessManager(dispatcher, "process", correlate, func(ctx context.Context, event es.Event, state *TestProcessState) (commands.Reaction, error) {
+				return commands.Reaction{
+					Steps: []commands.Step{{EntityID: "stock-1", Command: TestCommand{Value: "reserve"}}},
+				}, reactErr
+			})
+		)
+		_ = commands.RegisterFunc(dispatcher, "stock", record(&received))
+
+		// act
+		err := sut.Handle(t.Context(), event("order", "placed"))
+
+		// assert
+		assert.Truef(t, errors.Is(err, reactErr), "expected react error, got %v", err)
+		assert.Equal(t, 0, len(received))
+	})
+
+	t.Run("schedule timeouts", func(t *testing.T) {
+		// arrange
+		var (
+			now       = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			clock     = func() time.Time { return now }
+			scheduler = commands.NewMemoryScheduler(
+				commands.MemorySchedulerClock(clock),
+				commands.MemorySchedulerPollInterval(time.Millisecond),
+			)
+			dispatcher = newDispatcher(t, commands.WithScheduler(scheduler))
+			sut        = commands.NewProcessManager(dispatcher, "process", correlate, func(ctx context.Context, event es.Event, state *TestProcessState) (commands.Reaction, error) {
+				return commands.Reaction{
+					Timeouts: []commands.Timeout{{After: time.Hour, EntityID: "order-1", Command: TestCommand{Value: "expire"}}},
+				}, nil
+			}, commands.ProcessClock(clock))
+		)
+		_ = commands.RegisterFunc(dispatcher, "order", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		})
+
+		// act
+		err := sut.Handle(t.Context(), event("order", "placed"))
+
+		// assert
+		assert.NoError(t, err)
+		now = now.Add(time.Hour)
+		env, err := scheduler.Receive(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, "TestCommand", env.CommandName)
+		assert.Equal(t, "order-1", env.Metadata[commands.MetadataCorrelationID])
+	})
+}
@@ -0,0 +1,34 @@
+// Package protocodec is a commands.Codec for commands generated from protobuf definitions.
+package protocodec
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func New() *Codec {
+	return &Codec{}
+}
+
+// Codec uses the protobuf wire format. Commands must be registered as the pointer
+// type generated by protoc, with the CommandName method added in the same package.
+type Codec struct{}
+
+func (c *Codec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protocodec: %T is not a proto.Message", v)
+	}
+
+	return proto.Marshal(msg)
+}
+
+func (c *Codec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protocodec: %T is not a proto.Message", v)
+	}
+
+	return proto.Unmarshal(data, msg)
+}
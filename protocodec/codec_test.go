@@ -0,0 +1,75 @@
+package protocodec_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es-commands/protocodec"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type RenameAccount struct {
+	wrapperspb.StringValue
+}
+
+func (cmd *RenameAccount) CommandName() string {
+	return "RenameAccount"
+}
+
+type OpenAccount struct {
+	Owner string
+}
+
+func (cmd OpenAccount) CommandName() string {
+	return "OpenAccount"
+}
+
+type Account struct{}
+
+func (a *Account) Handle(ctx context.Context, event es.Event) error {
+	return nil
+}
+
+func TestCodec(t *testing.T) {
+	var (
+		dispatcher = commands.New(nil, commands.WithCodec(protocodec.New()))
+	)
+
+	assert.NoError(t, commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd *RenameAccount, state *Account) ([]es.Content, error) {
+		return nil, nil
+	}))
+	assert.NoError(t, commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd OpenAccount, state *Account) ([]es.Content, error) {
+		return nil, nil
+	}))
+
+	t.Run("encode and decode proto command", func(t *testing.T) {
+		// arrange
+		var cmd = &RenameAccount{}
+		cmd.Value = "new name"
+
+		// act
+		data, err := dispatcher.Encode(cmd)
+		assert.NoError(t, err)
+		got, err := dispatcher.Decode("RenameAccount", data)
+
+		// assert
+		assert.NoError(t, err)
+		renamed, ok := got.(*RenameAccount)
+		if assert.Truef(t, ok, "expected *RenameAccount, got %T", got) {
+			assert.Equal(t, "new name", renamed.GetValue())
+		}
+	})
+
+	t.Run("fail with non proto command", func(t *testing.T) {
+		// act
+		_, encodeErr := dispatcher.Encode(OpenAccount{Owner: "owner"})
+		_, decodeErr := dispatcher.Decode("OpenAccount", []byte{})
+
+		// assert
+		assert.Error(t, encodeErr)
+		assert.Error(t, decodeErr)
+	})
+}
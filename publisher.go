@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kyuff/es"
+)
+
+var ErrPublish = errors.New("publish")
+
+// PublishError is reported to the func set by WithOnError when the events of a command were
+// written, but the Publisher failed. The command itself succeeded.
+type PublishError struct {
+	EntityType string
+	EntityID   string
+	Err        error
+}
+
+func (e *PublishError) Error() string {
+	return fmt.Sprintf("publish events of %s %q: %s", e.EntityType, e.EntityID, e.Err)
+}
+
+func (e *PublishError) Unwrap() []error {
+	return []error{ErrPublish, e.Err}
+}
+
+func (e *PublishError) ErrorKind() string {
+	return "publish"
+}
+
+// Publication is the events written by a command.
+type Publication struct {
+	CommandName string
+	// Tenant the command was dispatched for, if the Dispatcher uses WithTenants.
+	Tenant string
+	// EntityType of the stream in the Store, which is prefixed with the tenant unless
+	// the tenants have their own TenantStores.
+	EntityType string
+	EntityID   string
+	// Position of the stream after the events were written.
+	Position int64
+	Events   []es.Content
+	Metadata Metadata
+}
+
+// EventNumber of the event at index i in Events.
+func (p Publication) EventNumber(i int) int64 {
+	return p.Position - int64(len(p.Events)) + int64(i) + 1
+}
+
+// Publisher receives the events written by commands after they are committed to the Store.
+// A failing Publisher does not fail the command, as its events are already committed.
+type Publisher interface {
+	Publish(ctx context.Context, pub Publication) error
+}
+
+type PublisherFunc func(ctx context.Context, pub Publication) error
+
+func (fn PublisherFunc) Publish(ctx context.Context, pub Publication) error {
+	return fn(ctx, pub)
+}
+
+// WithPublisher sets the Publisher of the events written by commands.
+func WithPublisher(publisher Publisher) Option {
+	return func(cfg *config) {
+		cfg.publisher = publisher
+	}
+}
+
+// publish the events written by the command, reporting a failure with onError.
+func publish(ctx context.Context, cfg *config, stream es.Stream, tenant, entityType, entityID string, command Command, events []es.Content) {
+	if cfg.publisher == nil {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	err := cfg.publisher.Publish(ctx, Publication{
+		CommandName: command.CommandName(),
+		Tenant:      tenant,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Position:    stream.Position(),
+		Events:      events,
+		Metadata:    MetadataFromContext(ctx),
+	})
+	if err != nil {
+		cfg.onError(ctx, &PublishError{EntityType: entityType, EntityID: entityID, Err: err})
+	}
+}
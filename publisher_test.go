@@ -0,0 +1,200 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestPublisher(t *testing.T) {
+	var (
+		newStore = func(write func(events ...es.Content) error) (*StoreMock, *StreamMock) {
+			var stream = &StreamMock{
+				ProjectFunc: func(handler es.Handler) error {
+					return nil
+				},
+				WriteFunc: write,
+				PositionFunc: func() int64 {
+					return 5
+				},
+				CloseFunc: func() error {
+					return nil
+				},
+			}
+			return &StoreMock{
+				OpenFunc: func(ctx context.Context, entityType string, entityID string) es.Stream {
+					return stream
+				},
+			}, stream
+		}
+		noWriteErr = func(events ...es.Content) error {
+			return nil
+		}
+		twoEvents = func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return []es.Content{TestEvent{Value: "first"}, TestEvent{Value: "second"}}, nil
+		}
+	)
+
+	t.Run("publish written events", func(t *testing.T) {
+		// arrange
+		var (
+			store, _  = newStore(noWriteErr)
+			published []commands.Publication
+			publisher = commands.PublisherFunc(func(ctx context.Context, pub commands.Publication) error {
+				published = append(published, pub)
+				return nil
+			})
+			dispatcher = commands.New(store, commands.WithPublisher(publisher))
+			ctx        = commands.ContextWithMetadata(t.Context(), commands.Metadata{"tenant": "tenant-1"})
+		)
+		_ = commands.RegisterFunc(dispatcher, "account", twoEvents)
+
+		// act
+		err := dispatcher.Dispatch(ctx, "account-1", TestCommand{})
+
+		// assert
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(published)) {
+			var pub = published[0]
+			assert.Equal(t, "TestCommand", pub.CommandName)
+			assert.Equal(t, "account", pub.EntityType)
+			assert.Equal(t, "account-1", pub.EntityID)
+			assert.Equal(t, int64(5), pub.Position)
+			assert.Equal(t, 2, len(pub.Events))
+			assert.Equal(t, int64(4), pub.EventNumber(0))
+			assert.Equal(t, int64(5), pub.EventNumber(1))
+			assert.Equal(t, "tenant-1", pub.Metadata["tenant"])
+		}
+	})
+
+	t.Run("publish tenant with the entity type of the stream", func(t *testing.T) {
+		// arrange
+		var (
+			store, _  = newStore(noWriteErr)
+			published []commands.Publication
+			publisher = commands.PublisherFunc(func(ctx context.Context, pub commands.Publication) error {
+				published = append(published, pub)
+				return nil
+			})
+			dispatcher = commands.New(store, commands.WithPublisher(publisher), commands.WithTenants())
+			ctx        = commands.ContextWithTenant(t.Context(), "tenant-1")
+		)
+		_ = commands.RegisterFunc(dispatcher, "account", twoEvents)
+
+		// act
+		err := dispatcher.Dispatch(ctx, "account-1", TestCommand{})
+
+		// assert
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(published)) {
+			assert.Equal(t, "tenant-1", published[0].Tenant)
+			assert.Equal(t, "tenant-1.account", published[0].EntityType)
+		}
+	})
+
+	t.Run("skip publishing without events", func(t *testing.T) {
+		// arrange
+		var (
+			store, _  = newStore(noWriteErr)
+			called    = false
+			publisher = commands.PublisherFunc(func(ctx context.Context, pub commands.Publication) error {
+				called = true
+				return nil
+			})
+			dispatcher = commands.New(store, commands.WithPublisher(publisher))
+		)
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		})
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Truef(t, !called, "expected no publication")
+	})
+
+	t.Run("skip publishing when write fails", func(t *testing.T) {
+		// arrange
+		var (
+			writeErr = errors.New("write-error")
+			store, _ = newStore(func(events ...es.Content) error {
+				return writeErr
+			})
+			called    = false
+			publisher = commands.PublisherFunc(func(ctx context.Context, pub commands.Publication) error {
+				called = true
+				return nil
+			})
+			dispatcher = commands.New(store, commands.WithPublisher(publisher))
+		)
+		_ = commands.RegisterFunc(dispatcher, "account", twoEvents)
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, writeErr), "expected write error, got %v", err)
+		assert.Truef(t, !called, "expected no publication")
+	})
+
+	t.Run("report publish error without failing the command", func(t *testing.T) {
+		// arrange
+		var (
+			store, stream = newStore(noWriteErr)
+			publishErr    = errors.New("publish-error")
+			publisher     = commands.PublisherFunc(func(ctx context.Context, pub commands.Publication) error {
+				return publishErr
+			})
+			reported   error
+			dispatcher = commands.New(store,
+				commands.WithPublisher(publisher),
+				commands.WithOnError(func(ctx context.Context, err error) {
+					reported = err
+				}),
+			)
+		)
+		_ = commands.RegisterFunc(dispatcher, "account", twoEvents)
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{})
+
+		// assert
+		assert.NoError(t, err)
+		var pubErr *commands.PublishError
+		assert.Truef(t, errors.As(reported, &pubErr), "expected PublishError, got %v", reported)
+		assert.Truef(t, errors.Is(reported, commands.ErrPublish), "expected ErrPublish")
+		assert.Truef(t, errors.Is(reported, publishErr), "expected publish error")
+		assert.Equal(t, 1, len(stream.WriteCalls()))
+	})
+
+	t.Run("publish when the context is cancelled after write", func(t *testing.T) {
+		// arrange
+		var (
+			ctx, cancel = context.WithCancel(t.Context())
+			store, _    = newStore(func(events ...es.Content) error {
+				cancel()
+				return nil
+			})
+			published error
+			publisher = commands.PublisherFunc(func(ctx context.Context, pub commands.Publication) error {
+				published = ctx.Err()
+				return nil
+			})
+			dispatcher = commands.New(store, commands.WithPublisher(publisher))
+		)
+		_ = commands.RegisterFunc(dispatcher, "account", twoEvents)
+
+		// act
+		err := dispatcher.Dispatch(ctx, "account-1", TestCommand{})
+
+		// assert
+		assert.NoError(t, err)
+		assert.NoError(t, published)
+	})
+}
@@ -5,11 +5,71 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"time"
 
 	"github.com/kyuff/es"
 )
 
-func Register[C Command, S es.Handler](dispatcher *Dispatcher, entityType string, executor Executor[C, S]) (err error) {
+type RegisterOption func(reg *registration)
+
+// WithTimeout sets the time a command is allowed to run, including middleware.
+// It overrides the default timeout of the Dispatcher.
+func WithTimeout(timeout time.Duration) RegisterOption {
+	return func(reg *registration) {
+		reg.timeout = timeout
+	}
+}
+
+// withTypes overrides the command and state types of registrations that are not described by their type parameters.
+func withTypes(commandType, stateType reflect.Type) RegisterOption {
+	return func(reg *registration) {
+		reg.commandType = commandType
+		reg.stateType = stateType
+	}
+}
+
+// WithCommandCodec sets the Codec used for the command by Decode and Encode.
+func WithCommandCodec(codec Codec) RegisterOption {
+	return func(reg *registration) {
+		reg.codec = codec
+	}
+}
+
+func Register[C Command, S es.Handler](dispatcher *Dispatcher, entityType string, executor Executor[C, S], opts ...RegisterOption) error {
+	return register(dispatcher, entityType, nil, executor, false, opts...)
+}
+
+func RegisterFunc[C Command, S es.Handler](dispatcher *Dispatcher, entityType string, executor func(ctx context.Context, cmd C, state S) ([]es.Content, error), opts ...RegisterOption) error {
+	return Register(dispatcher, entityType, ExecutorFunc[C, S](executor), opts...)
+}
+
+// Replace swaps the executor of a registered command. Dispatches in flight finish with the
+// replaced executor, while dispatches started after Replace returns use the new one.
+func Replace[C Command, S es.Handler](dispatcher *Dispatcher, entityType string, executor Executor[C, S], opts ...RegisterOption) error {
+	return register(dispatcher, entityType, nil, executor, true, opts...)
+}
+
+func ReplaceFunc[C Command, S es.Handler](dispatcher *Dispatcher, entityType string, executor func(ctx context.Context, cmd C, state S) ([]es.Content, error), opts ...RegisterOption) error {
+	return Replace(dispatcher, entityType, ExecutorFunc[C, S](executor), opts...)
+}
+
+// Unregister removes the command registered by name. Dispatches in flight finish with its executor.
+func (d *Dispatcher) Unregister(name string) error {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if _, ok := d.executors[name]; !ok {
+		return fmt.Errorf("command %s: %w", name, ErrNotRegistered)
+	}
+
+	delete(d.executors, name)
+	return nil
+}
+
+// register the executor with states created by newState, or by the type of S if newState is nil.
+// With replace, the command must already be registered.
+func register[C Command, S es.Handler](dispatcher *Dispatcher, entityType string, newState func() S, executor Executor[C, S], replace bool, opts ...RegisterOption) (err error) {
 	dispatcher.mux.Lock()
 	defer dispatcher.mux.Unlock()
 
@@ -20,32 +80,86 @@ func Register[C Command, S es.Handler](dispatcher *Dispatcher, entityType string
 		}
 	}()
 
+	if newState == nil {
+		newState = newInstance[S]()
+	}
+
 	var name = getName[C]()
-	if _, ok := dispatcher.executors[name]; ok {
+	_, ok := dispatcher.executors[name]
+	switch {
+	case ok && !replace:
 		return fmt.Errorf("command already registered: %s", name)
+	case !ok && replace:
+		return fmt.Errorf("command %s: %w", name, ErrNotRegistered)
 	}
 
-	dispatcher.executors[name] = middlewareExecutor(
-		dispatcher.middlewares,
-		decorateExecutor(dispatcher.store, entityType, executor),
-	)
+	dispatcher.executors[name] = newRegistration(dispatcher, name, entityType, decodeCommand[C], newState, executor, opts)
 
 	return nil
 }
 
-func RegisterFunc[C Command, S es.Handler](dispatcher *Dispatcher, entityType string, executor func(ctx context.Context, cmd C, state S) ([]es.Content, error)) error {
-	return Register(dispatcher, entityType, ExecutorFunc[C, S](executor))
+// newRegistration must be called with the lock of the dispatcher held.
+func newRegistration[C Command, S es.Handler](dispatcher *Dispatcher, name, entityType string, decode func(decode func(v any) error) (Command, error), newState func() S, executor Executor[C, S], opts []RegisterOption) *registration {
+	var reg = &registration{
+		name:        name,
+		entityType:  entityType,
+		timeout:     dispatcher.cfg.defaultTimeout,
+		decode:      decode,
+		codec:       dispatcher.cfg.codec,
+		commandType: reflect.TypeFor[C](),
+		stateType:   reflect.TypeFor[S](),
+		middlewares: slices.Clone(dispatcher.cfg.middlewares),
+	}
+	for _, opt := range opts {
+		opt(reg)
+	}
+
+	reg.execute = middlewareExecutor(
+		reg,
+		dispatcher.cfg.middlewares,
+		decorateExecutor(dispatcher.store, dispatcher.cfg, entityType, newState, executor),
+	)
+
+	return reg
+}
+
+func decodeCommand[C Command](decode func(v any) error) (Command, error) {
+	return decodeCommandType(reflect.TypeFor[C]())(decode)
+}
+
+// decodeCommandType returns a decode func for commands of the given type.
+func decodeCommandType(typ reflect.Type) func(decode func(v any) error) (Command, error) {
+	var decodeValue = decodeType(typ)
+	return func(decode func(v any) error) (Command, error) {
+		v, err := decodeValue(decode)
+		return v.(Command), err
+	}
+}
+
+// decodeType returns a decode func for values of the given type.
+func decodeType(typ reflect.Type) func(decode func(v any) error) (any, error) {
+	return func(decode func(v any) error) (any, error) {
+		if typ.Kind() == reflect.Pointer {
+			var v = reflect.New(typ.Elem())
+			err := decode(v.Interface())
+			return v.Interface(), err
+		}
+
+		var v = reflect.New(typ)
+		err := decode(v.Interface())
+		return v.Elem().Interface(), err
+	}
 }
 
 func getName[C Command]() string {
-	typ := reflect.TypeFor[C]()
+	return commandName(reflect.TypeFor[C]())
+}
+
+// commandName of a zero value of the command type.
+func commandName(typ reflect.Type) string {
 	if typ.Kind() == reflect.Pointer {
-		typ = typ.Elem()
-		var c = reflect.New(typ).Interface().(C)
-		return c.CommandName()
-	} else {
-		var c C
-		//goland:noinspection ALL
-		return c.CommandName()
+		return reflect.New(typ.Elem()).Interface().(Command).CommandName()
 	}
+
+	return reflect.Zero(typ).Interface().(Command).CommandName()
 }
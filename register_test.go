@@ -5,11 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"math/rand/v2"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/kyuff/es"
 	commands "github.com/kyuff/es-commands"
 	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es/storage/inmemory"
 )
 
 func TestRegister(t *testing.T) {
@@ -98,3 +101,147 @@ func TestRegister(t *testing.T) {
 		})
 	}
 }
+
+func TestReplace(t *testing.T) {
+	var (
+		newDispatcher = func(t *testing.T) *commands.Dispatcher {
+			return commands.NewDispatcher(es.NewStore(inmemory.New()))
+		}
+		executor = func(calls *atomic.Int64) func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+				calls.Add(1)
+				return nil, nil
+			}
+		}
+	)
+
+	t.Run("dispatch to replaced executor", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = newDispatcher(t)
+			oldCalls   atomic.Int64
+			newCalls   atomic.Int64
+		)
+		assert.NoError(t, commands.RegisterFunc(dispatcher, "entity", executor(&oldCalls)))
+
+		// act
+		err := commands.ReplaceFunc(dispatcher, "entity", executor(&newCalls))
+
+		// assert
+		assert.NoError(t, err)
+		assert.NoError(t, dispatcher.Dispatch(t.Context(), "entity-1", TestCommand{}))
+		assert.Equal(t, int64(0), oldCalls.Load())
+		assert.Equal(t, int64(1), newCalls.Load())
+	})
+
+	t.Run("finish dispatches in flight with replaced executor", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = newDispatcher(t)
+			started    = make(chan struct{})
+			release    = make(chan struct{})
+			done       = make(chan error)
+			newCalls   atomic.Int64
+		)
+		assert.NoError(t, commands.RegisterFunc(dispatcher, "entity", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			close(started)
+			<-release
+			return nil, errors.New("old executor")
+		}))
+		go func() {
+			done <- dispatcher.Dispatch(t.Context(), "entity-1", TestCommand{})
+		}()
+		<-started
+
+		// act
+		err := commands.ReplaceFunc(dispatcher, "entity", executor(&newCalls))
+
+		// assert
+		assert.NoError(t, err)
+		assert.NoError(t, dispatcher.Dispatch(t.Context(), "entity-2", TestCommand{}))
+		assert.Equal(t, int64(1), newCalls.Load())
+		close(release)
+		assert.Equal(t, "old executor", (<-done).Error())
+	})
+
+	t.Run("fail when not registered", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = newDispatcher(t)
+			calls      atomic.Int64
+		)
+
+		// act
+		err := commands.ReplaceFunc(dispatcher, "entity", executor(&calls))
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNotRegistered), "expected ErrNotRegistered, got %v", err)
+		assert.Truef(t, !dispatcher.Has("TestCommand"), "expected no registration")
+	})
+
+	t.Run("replace and unregister concurrently with dispatches", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = newDispatcher(t)
+			calls      atomic.Int64
+			wg         sync.WaitGroup
+		)
+		assert.NoError(t, commands.RegisterFunc(dispatcher, "entity", executor(&calls)))
+
+		// act
+		for i := range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range 50 {
+					err := dispatcher.Dispatch(t.Context(), fmt.Sprintf("entity-%d-%d", i, j), TestCommand{})
+					if err != nil && !errors.Is(err, commands.ErrNotRegistered) {
+						t.Errorf("unexpected error: %v", err)
+					}
+				}
+			}()
+		}
+		for range 50 {
+			_ = commands.ReplaceFunc(dispatcher, "entity", executor(&calls))
+			_ = dispatcher.Unregister("TestCommand")
+			_ = commands.RegisterFunc(dispatcher, "entity", executor(&calls))
+		}
+		wg.Wait()
+
+		// assert
+		assert.Truef(t, dispatcher.Has("TestCommand"), "expected registration")
+	})
+}
+
+func TestDispatcher_Unregister(t *testing.T) {
+	t.Run("unregister command", func(t *testing.T) {
+		// arrange
+		var dispatcher = commands.NewDispatcher(es.NewStore(inmemory.New()))
+		assert.NoError(t, commands.RegisterFunc(dispatcher, "entity", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		}))
+
+		// act
+		err := dispatcher.Unregister("TestCommand")
+
+		// assert
+		assert.NoError(t, err)
+		assert.Truef(t, !dispatcher.Has("TestCommand"), "expected no registration")
+		err = dispatcher.Dispatch(t.Context(), "entity-1", TestCommand{})
+		assert.Truef(t, errors.Is(err, commands.ErrNotRegistered), "expected ErrNotRegistered, got %v", err)
+		assert.NoError(t, commands.RegisterFunc(dispatcher, "entity", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		}))
+	})
+
+	t.Run("fail when not registered", func(t *testing.T) {
+		// arrange
+		var dispatcher = commands.NewDispatcher(es.NewStore(inmemory.New()))
+
+		// act
+		err := dispatcher.Unregister("TestCommand")
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNotRegistered), "expected ErrNotRegistered, got %v", err)
+	})
+}
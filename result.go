@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/kyuff/es"
+)
+
+// Result of a dispatched command.
+type Result struct {
+	// Position of the stream after the command was executed.
+	Position int64
+	// Events written by the command.
+	Events []es.Content
+}
+
+type resultKey struct{}
+
+// DispatchResult works as Dispatch, but also returns the Result of the command.
+func (d *Dispatcher) DispatchResult(ctx context.Context, entityID string, cmd Command) (Result, error) {
+	var result Result
+	err := d.Dispatch(context.WithValue(ctx, resultKey{}, &result), entityID, cmd)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return result, nil
+}
+
+// takeResult returns the Result requested by DispatchResult and hides it from
+// commands dispatched further down the same context.
+func takeResult(ctx context.Context) (context.Context, *Result) {
+	result, ok := ctx.Value(resultKey{}).(*Result)
+	if !ok || result == nil {
+		return ctx, nil
+	}
+
+	return context.WithValue(ctx, resultKey{}, (*Result)(nil)), result
+}
@@ -0,0 +1,102 @@
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestDispatchResult(t *testing.T) {
+	var (
+		newStore = func(position int64) (*StoreMock, *StreamMock) {
+			var stream = &StreamMock{
+				ProjectFunc: func(handler es.Handler) error {
+					return nil
+				},
+				WriteFunc: func(events ...es.Content) error {
+					position += int64(len(events))
+					return nil
+				},
+				PositionFunc: func() int64 {
+					return position
+				},
+				CloseFunc: func() error {
+					return nil
+				},
+			}
+			return &StoreMock{
+				OpenFunc: func(ctx context.Context, entityType string, entityID string) es.Stream {
+					return stream
+				},
+			}, stream
+		}
+	)
+
+	t.Run("return position and events", func(t *testing.T) {
+		// arrange
+		var (
+			store, _   = newStore(3)
+			dispatcher = commands.NewDispatcher(store)
+			events     = []es.Content{&ContentMock{}, &ContentMock{}}
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return events, nil
+		})
+
+		// act
+		got, err := dispatcher.DispatchResult(t.Context(), "account-1", TestCommand{})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), got.Position)
+		assert.EqualSlice(t, events, got.Events)
+	})
+
+	t.Run("return position without events", func(t *testing.T) {
+		// arrange
+		var (
+			store, stream = newStore(3)
+			dispatcher    = commands.NewDispatcher(store)
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		})
+
+		// act
+		got, err := dispatcher.DispatchResult(t.Context(), "account-1", TestCommand{})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), got.Position)
+		assert.Equal(t, 0, len(got.Events))
+		assert.Equal(t, 0, len(stream.WriteCalls()))
+	})
+
+	t.Run("ignore commands dispatched by the executor", func(t *testing.T) {
+		// arrange
+		var (
+			store, _   = newStore(0)
+			dispatcher = commands.NewDispatcher(store)
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			err := dispatcher.Dispatch(ctx, "account-2", &TestPointerCommand{})
+			return []es.Content{&ContentMock{}}, err
+		})
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd *TestPointerCommand, state *StateMock) ([]es.Content, error) {
+			return []es.Content{&ContentMock{}, &ContentMock{}}, nil
+		})
+
+		// act
+		got, err := dispatcher.DispatchResult(t.Context(), "account-1", TestCommand{})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(got.Events))
+	})
+}
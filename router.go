@@ -0,0 +1,313 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var _ Registry = (*Router)(nil)
+
+type RouterOption func(cfg *routerConfig)
+
+type routerConfig struct {
+	routes []route
+}
+
+type routeKind int
+
+const (
+	routeCommand routeKind = iota
+	routePrefix
+	routeEntityType
+)
+
+type route struct {
+	kind       routeKind
+	key        string
+	dispatcher *Dispatcher
+}
+
+// RouteCommands routes the named commands to the dispatcher, including versions of them.
+func RouteCommands(dispatcher *Dispatcher, names ...string) RouterOption {
+	return func(cfg *routerConfig) {
+		for _, name := range names {
+			cfg.routes = append(cfg.routes, route{kind: routeCommand, key: name, dispatcher: dispatcher})
+		}
+	}
+}
+
+// RoutePrefix routes commands with names starting with prefix to the dispatcher.
+func RoutePrefix(prefix string, dispatcher *Dispatcher) RouterOption {
+	return func(cfg *routerConfig) {
+		cfg.routes = append(cfg.routes, route{kind: routePrefix, key: prefix, dispatcher: dispatcher})
+	}
+}
+
+// RouteEntityType routes the commands registered in the dispatcher with the entity type to it.
+func RouteEntityType(entityType string, dispatcher *Dispatcher) RouterOption {
+	return func(cfg *routerConfig) {
+		cfg.routes = append(cfg.routes, route{kind: routeEntityType, key: entityType, dispatcher: dispatcher})
+	}
+}
+
+// NewRouter composes dispatchers into one CommandBus. Commands are routed by the name of
+// the command, then by the longest matching prefix and then by the entity type they are registered with.
+//
+// It fails when a command registered in one of the dispatchers is routed to another dispatcher
+// as well, such as by a prefix shadowing its entity type, and when a command routed by name is
+// not registered in its dispatcher.
+func NewRouter(opts ...RouterOption) (*Router, error) {
+	var cfg = &routerConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var r = &Router{
+		commands: make(map[string]*Dispatcher),
+	}
+
+	var errs []error
+	for _, rt := range cfg.routes {
+		if rt.dispatcher == nil {
+			errs = append(errs, fmt.Errorf("route %q has no dispatcher", rt.key))
+			continue
+		}
+
+		if !slices.Contains(r.dispatchers, rt.dispatcher) {
+			r.dispatchers = append(r.dispatchers, rt.dispatcher)
+		}
+
+		switch rt.kind {
+		case routeCommand:
+			if _, ok := r.commands[rt.key]; ok {
+				errs = append(errs, fmt.Errorf("command %s is routed more than once", rt.key))
+			}
+			if !rt.dispatcher.Has(rt.key) {
+				errs = append(errs, fmt.Errorf("command %s: %w", rt.key, ErrNotRegistered))
+			}
+			r.commands[rt.key] = rt.dispatcher
+		case routePrefix:
+			if slices.ContainsFunc(r.prefixes, func(other route) bool { return other.key == rt.key }) {
+				errs = append(errs, fmt.Errorf("prefix %q is routed more than once", rt.key))
+			}
+			r.prefixes = append(r.prefixes, rt)
+		case routeEntityType:
+			if slices.ContainsFunc(r.entityTypes, func(other route) bool { return other.key == rt.key }) {
+				errs = append(errs, fmt.Errorf("entity type %q is routed more than once", rt.key))
+			}
+			r.entityTypes = append(r.entityTypes, rt)
+		}
+	}
+
+	slices.SortStableFunc(r.prefixes, func(a, b route) int {
+		return len(b.key) - len(a.key)
+	})
+
+	errs = append(errs, r.collisions()...)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return r, nil
+}
+
+// Router forwards commands to the Dispatcher they are routed to.
+type Router struct {
+	dispatchers []*Dispatcher
+	commands    map[string]*Dispatcher
+	// prefixes ordered by length, longest first.
+	prefixes    []route
+	entityTypes []route
+}
+
+// collisions returns an error for each command routed to a dispatcher, that is dispatched
+// with another dispatcher, as a route taking precedence matches it as well.
+func (r *Router) collisions() []error {
+	var errs []error
+	for _, dispatcher := range r.dispatchers {
+		for _, desc := range dispatcher.Commands() {
+			if !r.routes(dispatcher, desc) {
+				continue
+			}
+
+			if routed, err := r.route(desc.Name); err != nil || routed != dispatcher {
+				errs = append(errs, fmt.Errorf("command %s is routed to more than one dispatcher", desc.Name))
+			}
+		}
+	}
+
+	return errs
+}
+
+// routes reports whether any route matches the command to the dispatcher.
+func (r *Router) routes(dispatcher *Dispatcher, desc Descriptor) bool {
+	if r.commands[desc.Name] == dispatcher {
+		return true
+	}
+
+	for _, rt := range r.prefixes {
+		if rt.dispatcher == dispatcher && strings.HasPrefix(desc.Name, rt.key) {
+			return true
+		}
+	}
+
+	for _, rt := range r.entityTypes {
+		if rt.dispatcher == dispatcher && desc.EntityType == rt.key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// route returns the Dispatcher the command name is routed to.
+func (r *Router) route(name string) (*Dispatcher, error) {
+	if dispatcher, ok := r.commands[name]; ok {
+		return dispatcher, nil
+	}
+
+	if base, ok := versionBase(name); ok {
+		if dispatcher, ok := r.commands[base]; ok {
+			return dispatcher, nil
+		}
+	}
+
+	for _, rt := range r.prefixes {
+		if strings.HasPrefix(name, rt.key) {
+			return rt.dispatcher, nil
+		}
+	}
+
+	for _, rt := range r.entityTypes {
+		if entityType, ok := rt.dispatcher.EntityType(name); ok && entityType == rt.key {
+			return rt.dispatcher, nil
+		}
+	}
+
+	return nil, fmt.Errorf("command %s: %w", name, ErrNotRegistered)
+}
+
+func (r *Router) Dispatch(ctx context.Context, entityID string, cmd Command) error {
+	if cmd == nil {
+		return fmt.Errorf("command %T is nil", cmd)
+	}
+
+	dispatcher, err := r.route(cmd.CommandName())
+	if err != nil {
+		return err
+	}
+
+	return dispatcher.Dispatch(ctx, entityID, cmd)
+}
+
+// DispatchResult works as Dispatch, but also returns the Result of the command.
+func (r *Router) DispatchResult(ctx context.Context, entityID string, cmd Command) (Result, error) {
+	if cmd == nil {
+		return Result{}, fmt.Errorf("command %T is nil", cmd)
+	}
+
+	dispatcher, err := r.route(cmd.CommandName())
+	if err != nil {
+		return Result{}, err
+	}
+
+	return dispatcher.DispatchResult(ctx, entityID, cmd)
+}
+
+// Commands returns the descriptors of the routed commands of all dispatchers ordered by name.
+func (r *Router) Commands() []Descriptor {
+	var descriptors []Descriptor
+	for _, dispatcher := range r.dispatchers {
+		for _, desc := range dispatcher.Commands() {
+			if routed, err := r.route(desc.Name); err == nil && routed == dispatcher {
+				descriptors = append(descriptors, desc)
+			}
+		}
+	}
+
+	slices.SortFunc(descriptors, func(a, b Descriptor) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return descriptors
+}
+
+// Has reports whether a command is registered by name in the dispatcher it is routed to.
+func (r *Router) Has(name string) bool {
+	dispatcher, err := r.route(name)
+	return err == nil && dispatcher.Has(name)
+}
+
+// Lookup returns the Descriptor of the command in the dispatcher it is routed to.
+func (r *Router) Lookup(name string) (Descriptor, bool) {
+	dispatcher, err := r.route(name)
+	if err != nil {
+		return Descriptor{}, false
+	}
+
+	return dispatcher.Lookup(name)
+}
+
+// EntityType returns the entity type the command name is registered with in the dispatcher it is routed to.
+func (r *Router) EntityType(name string) (string, bool) {
+	dispatcher, err := r.route(name)
+	if err != nil {
+		return "", false
+	}
+
+	return dispatcher.EntityType(name)
+}
+
+// Decode a command with the dispatcher it is routed to.
+func (r *Router) Decode(name string, data []byte) (Command, error) {
+	dispatcher, err := r.route(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return dispatcher.Decode(name, data)
+}
+
+// DecodeFunc decodes a command with the dispatcher it is routed to.
+func (r *Router) DecodeFunc(name string, decode func(v any) error) (Command, error) {
+	dispatcher, err := r.route(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return dispatcher.DecodeFunc(name, decode)
+}
+
+// Encode a command with the dispatcher it is routed to.
+func (r *Router) Encode(cmd Command) ([]byte, error) {
+	if cmd == nil {
+		return nil, fmt.Errorf("command %T is nil", cmd)
+	}
+
+	dispatcher, err := r.route(cmd.CommandName())
+	if err != nil {
+		return nil, err
+	}
+
+	return dispatcher.Encode(cmd)
+}
+
+// Reject records the command in the Journal of the dispatcher it is routed to. Commands that
+// are not routed are recorded by the first dispatcher of the Router with a Journal.
+func (r *Router) Reject(ctx context.Context, entityID, commandName string, payload []byte, err error) error {
+	dispatcher, routeErr := r.route(commandName)
+	if routeErr == nil {
+		return dispatcher.Reject(ctx, entityID, commandName, payload, err)
+	}
+
+	for _, dispatcher := range r.dispatchers {
+		if dispatcher.cfg.journal != nil {
+			return dispatcher.Reject(ctx, entityID, commandName, payload, err)
+		}
+	}
+
+	return err
+}
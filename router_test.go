@@ -0,0 +1,288 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es/storage/inmemory"
+)
+
+func TestRouter(t *testing.T) {
+	var (
+		newDispatcher = func(t *testing.T, entityType string, calls *[]string, register ...func(d *commands.Dispatcher, entityType string, calls *[]string) error) *commands.Dispatcher {
+			var dispatcher = commands.NewDispatcher(es.NewStore(inmemory.New()))
+			for _, fn := range register {
+				assert.NoError(t, fn(dispatcher, entityType, calls))
+			}
+			return dispatcher
+		}
+		testCommand = func(d *commands.Dispatcher, entityType string, calls *[]string) error {
+			return commands.RegisterFunc(d, entityType, func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+				*calls = append(*calls, entityType+"/"+cmd.CommandName())
+				return nil, nil
+			})
+		}
+		pointerCommand = func(d *commands.Dispatcher, entityType string, calls *[]string) error {
+			return commands.RegisterFunc(d, entityType, func(ctx context.Context, cmd *TestPointerCommand, state *StateMock) ([]es.Content, error) {
+				*calls = append(*calls, entityType+"/"+cmd.CommandName())
+				return nil, nil
+			})
+		}
+	)
+
+	t.Run("route by command name", func(t *testing.T) {
+		// arrange
+		var (
+			calls    []string
+			accounts = newDispatcher(t, "account", &calls, testCommand)
+			orders   = newDispatcher(t, "order", &calls, pointerCommand)
+		)
+		sut, err := commands.NewRouter(
+			commands.RouteCommands(accounts, "TestCommand"),
+			commands.RouteCommands(orders, "TestPointerCommand"),
+		)
+		assert.NoError(t, err)
+
+		// act
+		errCommand := sut.Dispatch(t.Context(), "entity-1", TestCommand{})
+		_, errPointer := sut.DispatchResult(t.Context(), "entity-1", &TestPointerCommand{})
+
+		// assert
+		assert.NoError(t, errCommand)
+		assert.NoError(t, errPointer)
+		assert.EqualSlice(t, []string{"account/TestCommand", "order/TestPointerCommand"}, calls)
+	})
+
+	t.Run("route by longest prefix", func(t *testing.T) {
+		// arrange
+		var (
+			calls    []string
+			accounts = newDispatcher(t, "account", &calls, testCommand)
+			orders   = newDispatcher(t, "order", &calls, pointerCommand)
+		)
+		sut, err := commands.NewRouter(
+			commands.RoutePrefix("Test", accounts),
+			commands.RoutePrefix("TestPointer", orders),
+		)
+		assert.NoError(t, err)
+
+		// act
+		errCommand := sut.Dispatch(t.Context(), "entity-1", TestCommand{})
+		errPointer := sut.Dispatch(t.Context(), "entity-1", &TestPointerCommand{})
+
+		// assert
+		assert.NoError(t, errCommand)
+		assert.NoError(t, errPointer)
+		assert.EqualSlice(t, []string{"account/TestCommand", "order/TestPointerCommand"}, calls)
+	})
+
+	t.Run("route by entity type", func(t *testing.T) {
+		// arrange
+		var (
+			calls    []string
+			accounts = newDispatcher(t, "account", &calls, testCommand)
+			orders   = newDispatcher(t, "order", &calls, pointerCommand)
+		)
+		sut, err := commands.NewRouter(
+			commands.RouteEntityType("account", accounts),
+			commands.RouteEntityType("order", orders),
+		)
+		assert.NoError(t, err)
+
+		// act
+		errCommand := sut.Dispatch(t.Context(), "entity-1", TestCommand{})
+		errPointer := sut.Dispatch(t.Context(), "entity-1", &TestPointerCommand{})
+
+		// assert
+		assert.NoError(t, errCommand)
+		assert.NoError(t, errPointer)
+		assert.EqualSlice(t, []string{"account/TestCommand", "order/TestPointerCommand"}, calls)
+	})
+
+	t.Run("fail for commands not routed", func(t *testing.T) {
+		// arrange
+		var (
+			calls    []string
+			accounts = newDispatcher(t, "account", &calls, testCommand, pointerCommand)
+		)
+		sut, err := commands.NewRouter(commands.RouteCommands(accounts, "TestCommand"))
+		assert.NoError(t, err)
+
+		// act
+		err = sut.Dispatch(t.Context(), "entity-1", &TestPointerCommand{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNotRegistered), "expected ErrNotRegistered, got %v", err)
+		assert.Equal(t, 0, len(calls))
+	})
+
+	t.Run("merge registries", func(t *testing.T) {
+		// arrange
+		var (
+			calls    []string
+			accounts = newDispatcher(t, "account", &calls, testCommand, pointerCommand)
+			orders   = newDispatcher(t, "order", &calls, pointerCommand)
+		)
+		sut, err := commands.NewRouter(
+			commands.RouteCommands(accounts, "TestCommand"),
+			commands.RouteEntityType("order", orders),
+		)
+		assert.NoError(t, err)
+
+		// act
+		got := sut.Commands()
+
+		// assert
+		if assert.Equal(t, 2, len(got)) {
+			assert.Equal(t, "TestCommand", got[0].Name)
+			assert.Equal(t, "account", got[0].EntityType)
+			assert.Equal(t, "TestPointerCommand", got[1].Name)
+			assert.Equal(t, "order", got[1].EntityType)
+		}
+		assert.Truef(t, sut.Has("TestCommand"), "expected TestCommand")
+		entityType, ok := sut.EntityType("TestPointerCommand")
+		assert.Truef(t, ok, "expected entity type")
+		assert.Equal(t, "order", entityType)
+		desc, ok := sut.Lookup("TestPointerCommand")
+		assert.Truef(t, ok, "expected descriptor")
+		assert.Equal(t, "order", desc.EntityType)
+	})
+
+	t.Run("decode and encode with routed dispatcher", func(t *testing.T) {
+		// arrange
+		var (
+			calls    []string
+			accounts = newDispatcher(t, "account", &calls, testCommand)
+		)
+		sut, err := commands.NewRouter(commands.RoutePrefix("Test", accounts))
+		assert.NoError(t, err)
+
+		// act
+		data, errEncode := sut.Encode(TestCommand{Value: "value"})
+		got, errDecode := sut.Decode("TestCommand", data)
+
+		// assert
+		assert.NoError(t, errEncode)
+		assert.NoError(t, errDecode)
+		assert.Equal(t, commands.Command(TestCommand{Value: "value"}), got)
+	})
+
+	t.Run("journal rejected commands with routed dispatcher", func(t *testing.T) {
+		// arrange
+		var (
+			entries []string
+			journal = func(name string) commands.Option {
+				return commands.WithJournal(commands.JournalFunc(func(ctx context.Context, entry commands.JournalEntry) error {
+					entries = append(entries, name+"/"+entry.CommandName)
+					return nil
+				}))
+			}
+			accounts = commands.New(es.NewStore(inmemory.New()), journal("account"))
+			orders   = commands.New(es.NewStore(inmemory.New()), journal("order"))
+			errTest  = errors.New("test-error")
+		)
+		sut, err := commands.NewRouter(
+			commands.RoutePrefix("TestPointer", orders),
+			commands.RoutePrefix("Test", accounts),
+		)
+		assert.NoError(t, err)
+
+		// act
+		errRouted := sut.Reject(t.Context(), "entity-1", "TestPointerCommand", []byte(`{`), errTest)
+		errUnrouted := sut.Reject(t.Context(), "entity-1", "Unknown", []byte(`{`), errTest)
+
+		// assert
+		assert.Truef(t, errors.Is(errRouted, errTest), "expected test error, got %v", errRouted)
+		assert.Truef(t, errors.Is(errUnrouted, errTest), "expected test error, got %v", errUnrouted)
+		assert.EqualSlice(t, []string{"order/TestPointerCommand", "order/Unknown"}, entries)
+	})
+
+	var compositionErrors = []struct {
+		name string
+		opts func(accounts, orders *commands.Dispatcher) []commands.RouterOption
+	}{
+		{
+			name: "command registered in two routed dispatchers",
+			opts: func(accounts, orders *commands.Dispatcher) []commands.RouterOption {
+				return []commands.RouterOption{
+					commands.RouteEntityType("account", accounts),
+					commands.RoutePrefix("Test", orders),
+				}
+			},
+		},
+		{
+			name: "prefix shadowing an entity type",
+			opts: func(accounts, orders *commands.Dispatcher) []commands.RouterOption {
+				return []commands.RouterOption{
+					commands.RouteEntityType("order", orders),
+					commands.RoutePrefix("TestPointer", accounts),
+				}
+			},
+		},
+		{
+			name: "command routed twice",
+			opts: func(accounts, orders *commands.Dispatcher) []commands.RouterOption {
+				return []commands.RouterOption{
+					commands.RouteCommands(accounts, "TestCommand"),
+					commands.RouteCommands(orders, "TestCommand"),
+				}
+			},
+		},
+		{
+			name: "command routed by name not registered",
+			opts: func(accounts, orders *commands.Dispatcher) []commands.RouterOption {
+				return []commands.RouterOption{
+					commands.RouteCommands(accounts, "TestPointerCommand"),
+				}
+			},
+		},
+		{
+			name: "prefix routed twice",
+			opts: func(accounts, orders *commands.Dispatcher) []commands.RouterOption {
+				return []commands.RouterOption{
+					commands.RoutePrefix("Test", accounts),
+					commands.RoutePrefix("Test", accounts),
+				}
+			},
+		},
+		{
+			name: "entity type routed twice",
+			opts: func(accounts, orders *commands.Dispatcher) []commands.RouterOption {
+				return []commands.RouterOption{
+					commands.RouteEntityType("account", accounts),
+					commands.RouteEntityType("account", orders),
+				}
+			},
+		},
+		{
+			name: "route without dispatcher",
+			opts: func(accounts, orders *commands.Dispatcher) []commands.RouterOption {
+				return []commands.RouterOption{
+					commands.RoutePrefix("Test", nil),
+				}
+			},
+		},
+	}
+
+	for _, tt := range compositionErrors {
+		t.Run("fail on "+tt.name, func(t *testing.T) {
+			// arrange
+			var (
+				calls    []string
+				accounts = newDispatcher(t, "account", &calls, testCommand)
+				orders   = newDispatcher(t, "order", &calls, testCommand, pointerCommand)
+			)
+
+			// act
+			sut, err := commands.NewRouter(tt.opts(accounts, orders)...)
+
+			// assert
+			assert.Error(t, err)
+			assert.Truef(t, sut == nil, "expected no router")
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNoScheduler  = errors.New("no scheduler")
+	ErrNotScheduled = errors.New("not scheduled")
+)
+
+// Scheduler keeps envelopes until they are due. It is a Source that only delivers an Envelope
+// once its time has come, so due commands are dispatched by running a Consumer on it.
+type Scheduler interface {
+	Source
+	// Schedule the Envelope to be delivered at the given time.
+	Schedule(ctx context.Context, at time.Time, env Envelope) error
+	// Cancel the Envelope with the given ID. It fails with ErrNotScheduled if the Envelope
+	// is unknown or already delivered.
+	Cancel(ctx context.Context, id string) error
+}
+
+// WithScheduler sets the Scheduler used by Dispatcher.Schedule.
+func WithScheduler(scheduler Scheduler) Option {
+	return func(cfg *config) {
+		cfg.scheduler = scheduler
+	}
+}
+
+// Schedule the command to be dispatched at the given time, using the Metadata in the context.
+// The returned ID is used to cancel it.
+func (d *Dispatcher) Schedule(ctx context.Context, at time.Time, entityID string, cmd Command) (string, error) {
+	if d.cfg.scheduler == nil {
+		return "", ErrNoScheduler
+	}
+
+	env, err := d.Envelope(ctx, entityID, cmd)
+	if err != nil {
+		return "", err
+	}
+
+	err = d.cfg.scheduler.Schedule(ctx, at, env)
+	if err != nil {
+		return "", fmt.Errorf("schedule %s: %w", env.CommandName, err)
+	}
+
+	return env.ID, nil
+}
+
+// CancelSchedule cancels a command scheduled with Schedule.
+func (d *Dispatcher) CancelSchedule(ctx context.Context, id string) error {
+	if d.cfg.scheduler == nil {
+		return ErrNoScheduler
+	}
+
+	return d.cfg.scheduler.Cancel(ctx, id)
+}
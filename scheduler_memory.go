@@ -0,0 +1,266 @@
+package commands
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type MemorySchedulerOption func(s *MemoryScheduler)
+
+// MemorySchedulerClock sets the clock used to decide when envelopes are due.
+func MemorySchedulerClock(now func() time.Time) MemorySchedulerOption {
+	return func(s *MemoryScheduler) {
+		s.now = now
+	}
+}
+
+// MemorySchedulerPollInterval sets how often the clock is checked while waiting for the next
+// Envelope to be due. Defaults to 1 second.
+func MemorySchedulerPollInterval(interval time.Duration) MemorySchedulerOption {
+	return func(s *MemoryScheduler) {
+		s.pollInterval = interval
+	}
+}
+
+// MemorySchedulerRetryDelay sets how long a nacked Envelope waits before it is delivered again. Defaults to 1 second.
+func MemorySchedulerRetryDelay(delay time.Duration) MemorySchedulerOption {
+	return func(s *MemoryScheduler) {
+		s.retryDelay = delay
+	}
+}
+
+// NewMemoryScheduler creates an in-process Scheduler. It keeps nothing across restarts.
+func NewMemoryScheduler(opts ...MemorySchedulerOption) *MemoryScheduler {
+	var s = &MemoryScheduler{
+		now:          time.Now,
+		pollInterval: time.Second,
+		retryDelay:   time.Second,
+		byID:         make(map[string]*scheduled),
+		inFlight:     make(map[string]*scheduled),
+		notify:       make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+type MemoryScheduler struct {
+	now          func() time.Time
+	pollInterval time.Duration
+	retryDelay   time.Duration
+
+	mux         sync.Mutex
+	queue       scheduleQueue
+	byID        map[string]*scheduled
+	inFlight    map[string]*scheduled
+	seq         int64
+	deadLetters []Envelope
+	notify      chan struct{}
+}
+
+func (s *MemoryScheduler) Schedule(ctx context.Context, at time.Time, env Envelope) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.push(&scheduled{at: at, queuedAt: at, env: env})
+}
+
+// push the item to the queue. It must be called with the lock held.
+func (s *MemoryScheduler) push(item *scheduled) error {
+	if _, ok := s.byID[item.env.ID]; ok {
+		return fmt.Errorf("envelope %s is already scheduled", item.env.ID)
+	}
+
+	if item.seq == 0 {
+		s.seq++
+		item.seq = s.seq
+	}
+
+	heap.Push(&s.queue, item)
+	s.byID[item.env.ID] = item
+	s.signal()
+	return nil
+}
+
+func (s *MemoryScheduler) Cancel(ctx context.Context, id string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	item, ok := s.byID[id]
+	if !ok {
+		return fmt.Errorf("cancel %s: %w", id, ErrNotScheduled)
+	}
+
+	heap.Remove(&s.queue, item.index)
+	delete(s.byID, id)
+	return nil
+}
+
+func (s *MemoryScheduler) Receive(ctx context.Context) (Envelope, error) {
+	for {
+		env, wait, ok := s.pop()
+		if ok {
+			return env, nil
+		}
+
+		timer := time.NewTimer(min(wait, s.pollInterval))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Envelope{}, ctx.Err()
+		case <-s.notify:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *MemoryScheduler) Ack(ctx context.Context, env Envelope) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.settle(env)
+	return nil
+}
+
+// Nack schedules the Envelope again after the retry delay, before any later envelopes for the entity.
+func (s *MemoryScheduler) Nack(ctx context.Context, env Envelope, cause error) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	var item = &scheduled{at: s.now().Add(s.retryDelay), env: env}
+	if received, ok := s.inFlight[env.EntityID]; ok && received.env.ID == env.ID {
+		item.queuedAt, item.seq = received.queuedAt, received.seq
+	} else {
+		item.queuedAt = item.at
+	}
+
+	s.settle(env)
+	item.env.Attempts++
+	return s.push(item)
+}
+
+func (s *MemoryScheduler) DeadLetter(ctx context.Context, env Envelope, cause error) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.settle(env)
+	env.Attempts++
+	s.deadLetters = append(s.deadLetters, env)
+	return nil
+}
+
+// DeadLetters returns the envelopes that were given up on.
+func (s *MemoryScheduler) DeadLetters() []Envelope {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return append([]Envelope(nil), s.deadLetters...)
+}
+
+// settle the Envelope, letting the next Envelope for the entity be delivered. It must be called
+// with the lock held.
+func (s *MemoryScheduler) settle(env Envelope) {
+	if received, ok := s.inFlight[env.EntityID]; ok && received.env.ID == env.ID {
+		delete(s.inFlight, env.EntityID)
+		s.signal()
+	}
+}
+
+func (s *MemoryScheduler) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pop the next due Envelope that is not blocked by an earlier queued Envelope for the same
+// entity, or return how long until the next one is due.
+func (s *MemoryScheduler) pop() (Envelope, time.Duration, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	// the first queued Envelope of each entity is the only one that can be delivered
+	var heads = make(map[string]*scheduled)
+	for _, item := range s.queue {
+		head, ok := heads[item.env.EntityID]
+		if !ok || item.queuedBefore(head) {
+			heads[item.env.EntityID] = item
+		}
+	}
+
+	var (
+		now  = s.now()
+		next *scheduled
+	)
+	for entityID, head := range heads {
+		if _, ok := s.inFlight[entityID]; ok {
+			continue
+		}
+		if next == nil || head.at.Before(next.at) || (head.at.Equal(next.at) && head.seq < next.seq) {
+			next = head
+		}
+	}
+
+	if next == nil {
+		return Envelope{}, s.pollInterval, false
+	}
+
+	if wait := next.at.Sub(now); wait > 0 {
+		return Envelope{}, wait, false
+	}
+
+	heap.Remove(&s.queue, next.index)
+	delete(s.byID, next.env.ID)
+	s.inFlight[next.env.EntityID] = next
+	return next.env, 0, true
+}
+
+type scheduled struct {
+	at       time.Time
+	queuedAt time.Time
+	seq      int64
+	env      Envelope
+	index    int
+}
+
+func (item *scheduled) queuedBefore(other *scheduled) bool {
+	return item.queuedAt.Before(other.queuedAt) || (item.queuedAt.Equal(other.queuedAt) && item.seq < other.seq)
+}
+
+// scheduleQueue is a heap of envelopes ordered by the time they are due.
+type scheduleQueue []*scheduled
+
+func (q scheduleQueue) Len() int {
+	return len(q)
+}
+
+func (q scheduleQueue) Less(i, j int) bool {
+	return q[i].at.Before(q[j].at)
+}
+
+func (q scheduleQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *scheduleQueue) Push(x any) {
+	item := x.(*scheduled)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *scheduleQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return item
+}
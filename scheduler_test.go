@@ -0,0 +1,269 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestSchedule(t *testing.T) {
+	var (
+		newClock = func() (func() time.Time, func(d time.Duration)) {
+			var now atomic.Int64
+			now.Store(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+			return func() time.Time {
+					return time.Unix(0, now.Load()).UTC()
+				}, func(d time.Duration) {
+					now.Add(int64(d))
+				}
+		}
+		newStore = func() *StoreMock {
+			return &StoreMock{
+				OpenFunc: func(ctx context.Context, entityType string, entityID string) es.Stream {
+					return &StreamMock{
+						ProjectFunc: func(handler es.Handler) error {
+							return nil
+						},
+						WriteFunc: func(events ...es.Content) error {
+							return nil
+						},
+						CloseFunc: func() error {
+							return nil
+						},
+					}
+				},
+			}
+		}
+		newScheduler = func(now func() time.Time) *commands.MemoryScheduler {
+			return commands.NewMemoryScheduler(
+				commands.MemorySchedulerClock(now),
+				commands.MemorySchedulerPollInterval(time.Millisecond),
+			)
+		}
+		receive = func(t *testing.T, scheduler commands.Scheduler) (commands.Envelope, error) {
+			ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+			defer cancel()
+			return scheduler.Receive(ctx)
+		}
+		noop = func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		}
+	)
+
+	t.Run("fail without scheduler", func(t *testing.T) {
+		// arrange
+		var dispatcher = commands.NewDispatcher(newStore())
+		_ = commands.RegisterFunc(dispatcher, "account", noop)
+
+		// act
+		_, err := dispatcher.Schedule(t.Context(), time.Now(), "account-1", TestCommand{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNoScheduler), "expected ErrNoScheduler, got %v", err)
+	})
+
+	t.Run("fail scheduling unregistered command", func(t *testing.T) {
+		// arrange
+		var (
+			now, _     = newClock()
+			dispatcher = commands.New(newStore(), commands.WithScheduler(newScheduler(now)))
+		)
+
+		// act
+		_, err := dispatcher.Schedule(t.Context(), now(), "account-1", TestCommand{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNotRegistered), "expected ErrNotRegistered, got %v", err)
+	})
+
+	t.Run("deliver envelope when due", func(t *testing.T) {
+		// arrange
+		var (
+			now, advance = newClock()
+			scheduler    = newScheduler(now)
+			dispatcher   = commands.New(newStore(), commands.WithScheduler(scheduler))
+		)
+		_ = commands.RegisterFunc(dispatcher, "account", noop)
+
+		// act
+		id, err := dispatcher.Schedule(t.Context(), now().Add(time.Hour), "account-1", TestCommand{Value: "value"})
+
+		// assert
+		assert.NoError(t, err)
+		_, err = receive(t, scheduler)
+		assert.Truef(t, errors.Is(err, context.DeadlineExceeded), "expected nothing due, got %v", err)
+		advance(time.Hour)
+		env, err := receive(t, scheduler)
+		assert.NoError(t, err)
+		assert.Equal(t, id, env.ID)
+		assert.Equal(t, "account-1", env.EntityID)
+	})
+
+	t.Run("deliver envelopes in order of time", func(t *testing.T) {
+		// arrange
+		var (
+			now, advance = newClock()
+			sut          = newScheduler(now)
+		)
+		assert.NoError(t, sut.Schedule(t.Context(), now().Add(2*time.Minute), commands.Envelope{ID: "second", EntityID: "account-2"}))
+		assert.NoError(t, sut.Schedule(t.Context(), now().Add(3*time.Minute), commands.Envelope{ID: "third", EntityID: "account-3"}))
+		assert.NoError(t, sut.Schedule(t.Context(), now().Add(time.Minute), commands.Envelope{ID: "first", EntityID: "account-1"}))
+
+		// act
+		advance(time.Hour)
+
+		// assert
+		for _, id := range []string{"first", "second", "third"} {
+			env, err := receive(t, sut)
+			assert.NoError(t, err)
+			assert.Equal(t, id, env.ID)
+		}
+	})
+
+	t.Run("redeliver nacked envelope after delay", func(t *testing.T) {
+		// arrange
+		var (
+			now, advance = newClock()
+			sut          = commands.NewMemoryScheduler(
+				commands.MemorySchedulerClock(now),
+				commands.MemorySchedulerPollInterval(time.Millisecond),
+				commands.MemorySchedulerRetryDelay(time.Second),
+			)
+		)
+		assert.NoError(t, sut.Schedule(t.Context(), now(), commands.Envelope{ID: "envelope-1"}))
+		first, _ := receive(t, sut)
+
+		// act
+		err := sut.Nack(t.Context(), first, errors.New("executor-error"))
+
+		// assert
+		assert.NoError(t, err)
+		_, err = receive(t, sut)
+		assert.Truef(t, errors.Is(err, context.DeadlineExceeded), "expected nothing due, got %v", err)
+		advance(time.Second)
+		env, err := receive(t, sut)
+		assert.NoError(t, err)
+		assert.Equal(t, "envelope-1", env.ID)
+		assert.Equal(t, 1, env.Attempts)
+	})
+
+	t.Run("hold later envelopes for entity until nacked envelope is redelivered", func(t *testing.T) {
+		// arrange
+		var (
+			now, advance = newClock()
+			sut          = commands.NewMemoryScheduler(
+				commands.MemorySchedulerClock(now),
+				commands.MemorySchedulerPollInterval(time.Millisecond),
+				commands.MemorySchedulerRetryDelay(time.Minute),
+			)
+		)
+		assert.NoError(t, sut.Schedule(t.Context(), now(), commands.Envelope{ID: "first", EntityID: "account-1"}))
+		assert.NoError(t, sut.Schedule(t.Context(), now().Add(time.Second), commands.Envelope{ID: "second", EntityID: "account-1"}))
+		first, err := receive(t, sut)
+		assert.NoError(t, err)
+
+		// act
+		err = sut.Nack(t.Context(), first, errors.New("executor-error"))
+
+		// assert
+		assert.NoError(t, err)
+		advance(time.Second)
+		_, err = receive(t, sut)
+		assert.Truef(t, errors.Is(err, context.DeadlineExceeded), "expected later envelope to be held, got %v", err)
+		advance(time.Minute)
+		for _, id := range []string{"first", "second"} {
+			env, err := receive(t, sut)
+			assert.NoError(t, err)
+			assert.Equal(t, id, env.ID)
+			assert.NoError(t, sut.Ack(t.Context(), env))
+		}
+	})
+
+	t.Run("cancel scheduled command", func(t *testing.T) {
+		// arrange
+		var (
+			now, advance = newClock()
+			scheduler    = newScheduler(now)
+			dispatcher   = commands.New(newStore(), commands.WithScheduler(scheduler))
+		)
+		_ = commands.RegisterFunc(dispatcher, "account", noop)
+		id, err := dispatcher.Schedule(t.Context(), now().Add(time.Minute), "account-1", TestCommand{})
+		assert.NoError(t, err)
+
+		// act
+		err = dispatcher.CancelSchedule(t.Context(), id)
+
+		// assert
+		assert.NoError(t, err)
+		advance(time.Hour)
+		_, err = receive(t, scheduler)
+		assert.Truef(t, errors.Is(err, context.DeadlineExceeded), "expected nothing due, got %v", err)
+	})
+
+	t.Run("fail cancelling unknown command", func(t *testing.T) {
+		// arrange
+		var (
+			now, _     = newClock()
+			dispatcher = commands.New(newStore(), commands.WithScheduler(newScheduler(now)))
+		)
+
+		// act
+		err := dispatcher.CancelSchedule(t.Context(), "unknown")
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNotScheduled), "expected ErrNotScheduled, got %v", err)
+	})
+
+	t.Run("fail cancelling delivered command", func(t *testing.T) {
+		// arrange
+		var (
+			now, _ = newClock()
+			sut    = newScheduler(now)
+		)
+		assert.NoError(t, sut.Schedule(t.Context(), now(), commands.Envelope{ID: "envelope-1"}))
+		_, err := receive(t, sut)
+		assert.NoError(t, err)
+
+		// act
+		err = sut.Cancel(t.Context(), "envelope-1")
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNotScheduled), "expected ErrNotScheduled, got %v", err)
+	})
+
+	t.Run("dispatch due commands with consumer", func(t *testing.T) {
+		// arrange
+		var (
+			now, advance = newClock()
+			scheduler    = newScheduler(now)
+			dispatcher   = commands.New(newStore(), commands.WithScheduler(scheduler))
+			received     = make(chan string)
+			ctx, cancel  = context.WithCancel(t.Context())
+			done         = make(chan error)
+		)
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			received <- cmd.Value
+			return nil, nil
+		})
+		_, err := dispatcher.Schedule(t.Context(), now().Add(time.Minute), "account-1", TestCommand{Value: "value"})
+		assert.NoError(t, err)
+
+		go func() {
+			done <- commands.NewConsumer(dispatcher, scheduler).Run(ctx)
+		}()
+
+		// act
+		advance(time.Minute)
+
+		// assert
+		assert.Equal(t, "value", <-received)
+		cancel()
+		assert.NoError(t, <-done)
+	})
+}
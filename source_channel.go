@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+type ChannelSourceOption func(s *ChannelSource)
+
+// ChannelSourceRetryDelay sets how long a nacked envelope waits before it is delivered again. Defaults to 1 second.
+func ChannelSourceRetryDelay(delay time.Duration) ChannelSourceOption {
+	return func(s *ChannelSource) {
+		s.retryDelay = delay
+	}
+}
+
+func ChannelSourceClock(now func() time.Time) ChannelSourceOption {
+	return func(s *ChannelSource) {
+		s.now = now
+	}
+}
+
+// NewChannelSource creates an in-process Source. It keeps nothing across restarts.
+// Envelopes for an entity are not delivered while an earlier Envelope for it is received
+// and not settled, or is waiting to be delivered again.
+func NewChannelSource(opts ...ChannelSourceOption) *ChannelSource {
+	var s = &ChannelSource{
+		now:        time.Now,
+		retryDelay: time.Second,
+		inFlight:   make(map[string]bool),
+		notify:     make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+type ChannelSource struct {
+	now        func() time.Time
+	retryDelay time.Duration
+
+	mux         sync.Mutex
+	queue       []queued
+	inFlight    map[string]bool
+	deadLetters []Envelope
+	notify      chan struct{}
+}
+
+type queued struct {
+	env         Envelope
+	availableAt time.Time
+}
+
+// Send an Envelope to the Source.
+func (s *ChannelSource) Send(ctx context.Context, env Envelope) error {
+	s.mux.Lock()
+	s.queue = append(s.queue, queued{env: env, availableAt: s.now()})
+	s.mux.Unlock()
+
+	s.signal()
+	return nil
+}
+
+func (s *ChannelSource) Receive(ctx context.Context) (Envelope, error) {
+	for {
+		env, wait, ok := s.pop()
+		if ok {
+			return env, nil
+		}
+
+		if wait == 0 {
+			select {
+			case <-ctx.Done():
+				return Envelope{}, ctx.Err()
+			case <-s.notify:
+			}
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Envelope{}, ctx.Err()
+		case <-s.notify:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *ChannelSource) Ack(ctx context.Context, env Envelope) error {
+	s.settle(env)
+	return nil
+}
+
+// Nack delivers the Envelope again after the retry delay, before any later envelopes for the entity.
+func (s *ChannelSource) Nack(ctx context.Context, env Envelope, cause error) error {
+	s.mux.Lock()
+	env.Attempts++
+	s.queue = append([]queued{{env: env, availableAt: s.now().Add(s.retryDelay)}}, s.queue...)
+	delete(s.inFlight, env.EntityID)
+	s.mux.Unlock()
+
+	s.signal()
+	return nil
+}
+
+func (s *ChannelSource) DeadLetter(ctx context.Context, env Envelope, cause error) error {
+	s.mux.Lock()
+	env.Attempts++
+	s.deadLetters = append(s.deadLetters, env)
+	s.mux.Unlock()
+
+	s.settle(env)
+	return nil
+}
+
+// DeadLetters returns the envelopes that were given up on.
+func (s *ChannelSource) DeadLetters() []Envelope {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return append([]Envelope(nil), s.deadLetters...)
+}
+
+// settle the Envelope, letting the next Envelope for the entity be delivered.
+func (s *ChannelSource) settle(env Envelope) {
+	s.mux.Lock()
+	delete(s.inFlight, env.EntityID)
+	s.mux.Unlock()
+
+	s.signal()
+}
+
+func (s *ChannelSource) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pop the first Envelope that is available and not blocked by an earlier Envelope for the
+// same entity, or return how long until a waiting Envelope is available.
+func (s *ChannelSource) pop() (Envelope, time.Duration, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	var (
+		now     = s.now()
+		wait    time.Duration
+		blocked = make(map[string]bool)
+	)
+	for i, q := range s.queue {
+		if s.inFlight[q.env.EntityID] || blocked[q.env.EntityID] {
+			continue
+		}
+
+		if d := q.availableAt.Sub(now); d > 0 {
+			blocked[q.env.EntityID] = true
+			if wait == 0 || d < wait {
+				wait = d
+			}
+			continue
+		}
+
+		s.queue = slices.Delete(s.queue, i, i+1)
+		s.inFlight[q.env.EntityID] = true
+		if len(s.queue) > 0 {
+			s.signal()
+		}
+
+		return q.env, 0, true
+	}
+
+	return Envelope{}, wait, false
+}
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kyuff/es"
+)
+
+var ErrNoTenant = errors.New("no tenant")
+
+// MetadataTenant is the tenant a command is dispatched for.
+const MetadataTenant = "tenant"
+
+// ContextWithTenant returns a context with the tenant added to a copy of its Metadata,
+// so it travels with the envelopes of commands dispatched with it.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return withMetadata(ctx, MetadataTenant, tenant)
+}
+
+// TenantFromContext returns the tenant in the Metadata of the context.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := MetadataFromContext(ctx)[MetadataTenant]
+	return tenant, ok && tenant != ""
+}
+
+type TenantOption func(cfg *tenantConfig)
+
+// TenantStores routes streams to the Store of the tenant. Without it, the entity type
+// is prefixed with the tenant.
+func TenantStores(stores func(tenant string) (Store, error)) TenantOption {
+	return func(cfg *tenantConfig) {
+		cfg.stores = stores
+	}
+}
+
+// TenantEntityType sets how the entity type is prefixed with the tenant. Defaults to "<tenant>.<entityType>".
+func TenantEntityType(fn func(tenant, entityType string) string) TenantOption {
+	return func(cfg *tenantConfig) {
+		cfg.entityType = fn
+	}
+}
+
+// TenantResolver sets how the tenant is derived from the context. Defaults to TenantFromContext.
+func TenantResolver(fn func(ctx context.Context) (string, bool)) TenantOption {
+	return func(cfg *tenantConfig) {
+		cfg.resolve = fn
+	}
+}
+
+// TenantStrict rejects commands and streams without a tenant with ErrNoTenant.
+// Otherwise they use the decorated Store as is.
+func TenantStrict() TenantOption {
+	return func(cfg *tenantConfig) {
+		cfg.strict = true
+	}
+}
+
+type tenantConfig struct {
+	stores     func(tenant string) (Store, error)
+	entityType func(tenant, entityType string) string
+	resolve    func(ctx context.Context) (string, bool)
+	strict     bool
+}
+
+func newTenantConfig(opts []TenantOption) *tenantConfig {
+	var cfg = &tenantConfig{
+		entityType: func(tenant, entityType string) string {
+			return tenant + "." + entityType
+		},
+		resolve: TenantFromContext,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// WithTenants decorates the Store of the Dispatcher with a TenantStore. With TenantStrict,
+// commands dispatched without a tenant are rejected before their middleware.
+func WithTenants(opts ...TenantOption) Option {
+	return func(cfg *config) {
+		cfg.tenants = newTenantConfig(opts)
+	}
+}
+
+// NewTenantStore decorates a Store to separate the streams of tenants, either by opening them
+// in the Store of the tenant or by prefixing the entity type with the tenant.
+func NewTenantStore(store Store, opts ...TenantOption) *TenantStore {
+	return &TenantStore{
+		store: store,
+		cfg:   newTenantConfig(opts),
+	}
+}
+
+type TenantStore struct {
+	store Store
+	cfg   *tenantConfig
+}
+
+func (s *TenantStore) Open(ctx context.Context, entityType string, entityID string) es.Stream {
+	tenant, ok := s.cfg.resolve(ctx)
+	switch {
+	case !ok && s.cfg.strict:
+		return &errorStream{err: fmt.Errorf("open %s %q: %w", entityType, entityID, ErrNoTenant)}
+	case !ok:
+		return s.store.Open(ctx, entityType, entityID)
+	case s.cfg.stores != nil:
+		store, err := s.cfg.stores(tenant)
+		if err != nil {
+			return &errorStream{err: fmt.Errorf("store of tenant %q: %w", tenant, err)}
+		}
+		return store.Open(ctx, entityType, entityID)
+	default:
+		return s.store.Open(ctx, s.cfg.entityType(tenant, entityType), entityID)
+	}
+}
+
+// stream returns the tenant of the context and the entity type its streams of entityType have
+// in the Store. Without a tenant, the entity type is returned as is.
+func (cfg *tenantConfig) stream(ctx context.Context, entityType string) (string, string) {
+	if cfg == nil {
+		return "", entityType
+	}
+
+	tenant, ok := cfg.resolve(ctx)
+	switch {
+	case !ok:
+		return "", entityType
+	case cfg.stores != nil:
+		return tenant, entityType
+	default:
+		return tenant, cfg.entityType(tenant, entityType)
+	}
+}
+
+// store returns the tenant whose Store has the streams opened with the context. It is empty
+// when the tenants share a Store, as their entity types are then prefixed instead.
+func (cfg *tenantConfig) store(ctx context.Context) string {
+	if cfg == nil || cfg.stores == nil {
+		return ""
+	}
+
+	tenant, _ := cfg.resolve(ctx)
+	return tenant
+}
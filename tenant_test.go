@@ -0,0 +1,228 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es/storage/inmemory"
+)
+
+func TestTenantStore(t *testing.T) {
+	var (
+		newStore = func(opened *[]string) *StoreMock {
+			return &StoreMock{
+				OpenFunc: func(ctx context.Context, entityType string, entityID string) es.Stream {
+					*opened = append(*opened, entityType+"/"+entityID)
+					return &StreamMock{}
+				},
+			}
+		}
+	)
+
+	t.Run("prefix entity type with tenant", func(t *testing.T) {
+		// arrange
+		var (
+			opened []string
+			sut    = commands.NewTenantStore(newStore(&opened))
+			ctx    = commands.ContextWithTenant(t.Context(), "acme")
+		)
+
+		// act
+		_ = sut.Open(ctx, "account", "account-1")
+
+		// assert
+		assert.EqualSlice(t, []string{"acme.account/account-1"}, opened)
+	})
+
+	t.Run("prefix entity type with format", func(t *testing.T) {
+		// arrange
+		var (
+			opened []string
+			sut    = commands.NewTenantStore(newStore(&opened), commands.TenantEntityType(func(tenant, entityType string) string {
+				return tenant + "_" + entityType
+			}))
+			ctx = commands.ContextWithMetadata(t.Context(), commands.Metadata{commands.MetadataTenant: "acme"})
+		)
+
+		// act
+		_ = sut.Open(ctx, "account", "account-1")
+
+		// assert
+		assert.EqualSlice(t, []string{"acme_account/account-1"}, opened)
+	})
+
+	t.Run("open in store of tenant", func(t *testing.T) {
+		// arrange
+		var (
+			opened       []string
+			tenantOpened []string
+			tenantStore  = newStore(&tenantOpened)
+			sut          = commands.NewTenantStore(newStore(&opened), commands.TenantStores(func(tenant string) (commands.Store, error) {
+				assert.Equal(t, "acme", tenant)
+				return tenantStore, nil
+			}))
+		)
+
+		// act
+		_ = sut.Open(commands.ContextWithTenant(t.Context(), "acme"), "account", "account-1")
+
+		// assert
+		assert.Equal(t, 0, len(opened))
+		assert.EqualSlice(t, []string{"account/account-1"}, tenantOpened)
+	})
+
+	t.Run("fail when store of tenant fails", func(t *testing.T) {
+		// arrange
+		var (
+			opened   []string
+			errStore = errors.New("unknown tenant")
+			sut      = commands.NewTenantStore(newStore(&opened), commands.TenantStores(func(tenant string) (commands.Store, error) {
+				return nil, errStore
+			}))
+		)
+
+		// act
+		err := sut.Open(commands.ContextWithTenant(t.Context(), "acme"), "account", "account-1").Project(&StateMock{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, errStore), "expected store error, got %v", err)
+	})
+
+	t.Run("resolve tenant with func", func(t *testing.T) {
+		// arrange
+		var (
+			opened []string
+			sut    = commands.NewTenantStore(newStore(&opened), commands.TenantResolver(func(ctx context.Context) (string, bool) {
+				return "resolved", true
+			}))
+		)
+
+		// act
+		_ = sut.Open(t.Context(), "account", "account-1")
+
+		// assert
+		assert.EqualSlice(t, []string{"resolved.account/account-1"}, opened)
+	})
+
+	t.Run("open without tenant", func(t *testing.T) {
+		// arrange
+		var (
+			opened []string
+			sut    = commands.NewTenantStore(newStore(&opened))
+		)
+
+		// act
+		_ = sut.Open(t.Context(), "account", "account-1")
+
+		// assert
+		assert.EqualSlice(t, []string{"account/account-1"}, opened)
+	})
+
+	t.Run("fail without tenant when strict", func(t *testing.T) {
+		// arrange
+		var (
+			opened []string
+			sut    = commands.NewTenantStore(newStore(&opened), commands.TenantStrict())
+		)
+
+		// act
+		err := sut.Open(t.Context(), "account", "account-1").Project(&StateMock{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNoTenant), "expected ErrNoTenant, got %v", err)
+		assert.Equal(t, 0, len(opened))
+	})
+}
+
+func TestWithTenants(t *testing.T) {
+	var (
+		newDispatcher = func(t *testing.T, opts ...commands.TenantOption) (*commands.Dispatcher, *int) {
+			var (
+				calls  int
+				stores = make(map[string]commands.Store)
+			)
+			for _, tenant := range []string{"acme", "globex"} {
+				var storage = inmemory.New()
+				assert.NoError(t, storage.Register("account", TestEvent{}))
+				stores[tenant] = es.NewStore(storage)
+			}
+
+			var dispatcher = commands.New(es.NewStore(inmemory.New()), commands.WithTenants(append([]commands.TenantOption{
+				commands.TenantStores(func(tenant string) (commands.Store, error) {
+					store, ok := stores[tenant]
+					if !ok {
+						return nil, errors.New("unknown tenant")
+					}
+					return store, nil
+				}),
+			}, opts...)...))
+			assert.NoError(t, commands.RegisterDecider(dispatcher, "account", func(cmd TestCommand, state []string) ([]TestEvent, error) {
+				calls++
+				return []TestEvent{{Value: cmd.Value}}, nil
+			}, func(state []string, event TestEvent) []string {
+				return append(state, event.Value)
+			}))
+			return dispatcher, &calls
+		}
+	)
+
+	t.Run("separate streams of tenants", func(t *testing.T) {
+		// arrange
+		var dispatcher, _ = newDispatcher(t)
+		_, err := dispatcher.DispatchResult(commands.ContextWithTenant(t.Context(), "acme"), "account-1", TestCommand{Value: "first"})
+		assert.NoError(t, err)
+
+		// act
+		acme, errAcme := dispatcher.DispatchResult(commands.ContextWithTenant(t.Context(), "acme"), "account-1", TestCommand{Value: "second"})
+		globex, errGlobex := dispatcher.DispatchResult(commands.ContextWithTenant(t.Context(), "globex"), "account-1", TestCommand{Value: "first"})
+
+		// assert
+		assert.NoError(t, errAcme)
+		assert.NoError(t, errGlobex)
+		assert.Equal(t, int64(2), acme.Position)
+		assert.Equal(t, int64(1), globex.Position)
+	})
+
+	t.Run("derive tenant from envelope", func(t *testing.T) {
+		// arrange
+		var dispatcher, calls = newDispatcher(t, commands.TenantStrict())
+		env, err := dispatcher.Envelope(commands.ContextWithTenant(t.Context(), "acme"), "account-1", TestCommand{Value: "first"})
+		assert.NoError(t, err)
+
+		// act
+		err = dispatcher.DispatchEnvelope(t.Context(), env)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "acme", env.Metadata[commands.MetadataTenant])
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("reject commands without tenant when strict", func(t *testing.T) {
+		// arrange
+		var dispatcher, calls = newDispatcher(t, commands.TenantStrict())
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{Value: "first"})
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNoTenant), "expected ErrNoTenant, got %v", err)
+		assert.Equal(t, 0, *calls)
+	})
+
+	t.Run("fail for unknown tenant", func(t *testing.T) {
+		// arrange
+		var dispatcher, calls = newDispatcher(t)
+
+		// act
+		err := dispatcher.Dispatch(commands.ContextWithTenant(t.Context(), "initech"), "account-1", TestCommand{Value: "first"})
+
+		// assert
+		assert.Error(t, err)
+		assert.Equal(t, 0, *calls)
+	})
+}
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrCommandTimeout = errors.New("command timeout")
+
+type Phase string
+
+const (
+	PhaseMiddleware Phase = "middleware"
+	PhaseProject    Phase = "project"
+	PhaseExecute    Phase = "execute"
+	PhaseWrite      Phase = "write"
+)
+
+// TimeoutError is returned when a command did not complete within the timeout
+// it was registered with. Phase tells which part of the dispatch consumed the budget.
+type TimeoutError struct {
+	Name    string
+	Timeout time.Duration
+	Phase   Phase
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("command %s timed out after %s in %s: %s", e.Name, e.Timeout, e.Phase, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() []error {
+	return []error{ErrCommandTimeout, e.Err}
+}
+
+func (e *TimeoutError) ErrorKind() string {
+	return "timeout"
+}
@@ -0,0 +1,152 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	var (
+		newStore = func(project func(handler es.Handler) error) *StoreMock {
+			var stream = &StreamMock{
+				ProjectFunc: project,
+				WriteFunc: func(events ...es.Content) error {
+					return nil
+				},
+				CloseFunc: func() error {
+					return nil
+				},
+			}
+			return &StoreMock{
+				OpenFunc: func(ctx context.Context, entityType string, entityID string) es.Stream {
+					return stream
+				},
+			}
+		}
+		noProject = func(handler es.Handler) error {
+			return nil
+		}
+		blockingExecutor = func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+	)
+
+	t.Run("fail with timeout in execute phase", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = commands.NewDispatcher(newStore(noProject))
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", blockingExecutor, commands.WithTimeout(time.Millisecond))
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{})
+
+		// assert
+		var timeoutErr *commands.TimeoutError
+		assert.Truef(t, errors.As(err, &timeoutErr), "expected timeout error, got %v", err)
+		assert.Truef(t, errors.Is(err, commands.ErrCommandTimeout), "expected ErrCommandTimeout")
+		assert.Truef(t, errors.Is(err, context.DeadlineExceeded), "expected context.DeadlineExceeded")
+		assert.Equal(t, commands.PhaseExecute, timeoutErr.Phase)
+		assert.Equal(t, "TestCommand", timeoutErr.Name)
+		assert.Equal(t, time.Millisecond, timeoutErr.Timeout)
+	})
+
+	t.Run("fail with timeout in project phase", func(t *testing.T) {
+		// arrange
+		var (
+			store      = &StoreMock{}
+			dispatcher = commands.New(store, commands.WithDefaultTimeout(time.Millisecond))
+		)
+
+		store.OpenFunc = func(ctx context.Context, entityType string, entityID string) es.Stream {
+			return &StreamMock{
+				ProjectFunc: func(handler es.Handler) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+				CloseFunc: func() error {
+					return nil
+				},
+			}
+		}
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			return nil, nil
+		})
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{})
+
+		// assert
+		var timeoutErr *commands.TimeoutError
+		assert.Truef(t, errors.As(err, &timeoutErr), "expected timeout error, got %v", err)
+		assert.Equal(t, commands.PhaseProject, timeoutErr.Phase)
+	})
+
+	t.Run("fail with timeout in middleware phase", func(t *testing.T) {
+		// arrange
+		var (
+			middleware = commands.MiddlewareFunc(func(next func(ctx context.Context, command commands.Command) error) func(ctx context.Context, command commands.Command) error {
+				return func(ctx context.Context, command commands.Command) error {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+			})
+			dispatcher = commands.New(newStore(noProject), commands.WithMiddlewares(middleware))
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", blockingExecutor, commands.WithTimeout(time.Millisecond))
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{})
+
+		// assert
+		var timeoutErr *commands.TimeoutError
+		assert.Truef(t, errors.As(err, &timeoutErr), "expected timeout error, got %v", err)
+		assert.Equal(t, commands.PhaseMiddleware, timeoutErr.Phase)
+	})
+
+	t.Run("register timeout overrides default", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = commands.New(newStore(noProject), commands.WithDefaultTimeout(time.Millisecond))
+		)
+
+		_ = commands.RegisterFunc(dispatcher, "account", func(ctx context.Context, cmd TestCommand, state *StateMock) ([]es.Content, error) {
+			time.Sleep(5 * time.Millisecond)
+			return nil, ctx.Err()
+		}, commands.WithTimeout(time.Minute))
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommand{})
+
+		// assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("keep parent deadline errors", func(t *testing.T) {
+		// arrange
+		var (
+			ctx, cancel = context.WithTimeout(t.Context(), time.Millisecond)
+			dispatcher  = commands.NewDispatcher(newStore(noProject))
+		)
+		defer cancel()
+
+		_ = commands.RegisterFunc(dispatcher, "account", blockingExecutor, commands.WithTimeout(time.Minute))
+
+		// act
+		err := dispatcher.Dispatch(ctx, "account-1", TestCommand{})
+
+		// assert
+		assert.Truef(t, errors.Is(err, context.DeadlineExceeded), "expected context.DeadlineExceeded, got %v", err)
+		assert.Truef(t, !errors.Is(err, commands.ErrCommandTimeout), "expected no ErrCommandTimeout")
+	})
+}
@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+var ErrNoUpcast = errors.New("no upcast path")
+
+// UpcastError is returned when a previous version of a command cannot be upcast to the registered command.
+type UpcastError struct {
+	Name string
+	Err  error
+}
+
+func (e *UpcastError) Error() string {
+	return fmt.Sprintf("upcast command %s: %s", e.Name, e.Err)
+}
+
+func (e *UpcastError) Unwrap() []error {
+	return []error{ErrInvalidCommand, e.Err}
+}
+
+func (e *UpcastError) ErrorKind() string {
+	return "upcast"
+}
+
+type upcaster struct {
+	name     string
+	fromType reflect.Type
+	toType   reflect.Type
+	upcast   func(from any) (any, error)
+	decode   func(decode func(v any) error) (any, error)
+}
+
+// RegisterUpcast registers a previous version of a command under a versioned name, such as
+// OpenAccount.v1, with a func upcasting it to the next version. Following the upcasts from
+// the type To must lead to the command registered under the name without the version.
+//
+// Versions are upcast before the middleware of the command, both when they are dispatched and when
+// they are decoded by name. They are decoded with the Codec of the command.
+func RegisterUpcast[From, To any](dispatcher *Dispatcher, name string, upcast func(from From) (To, error)) error {
+	if _, ok := versionBase(name); !ok {
+		return fmt.Errorf("upcast %s: name must be <command>.v<version>", name)
+	}
+
+	var (
+		fromType = reflect.TypeFor[From]()
+		toType   = reflect.TypeFor[To]()
+	)
+	if fromType == toType {
+		return fmt.Errorf("upcast %s: %s is upcast to itself", name, fromType)
+	}
+
+	dispatcher.mux.Lock()
+	defer dispatcher.mux.Unlock()
+
+	if _, ok := dispatcher.executors[name]; ok {
+		return fmt.Errorf("command already registered: %s", name)
+	}
+	if _, ok := dispatcher.upcasters[name]; ok {
+		return fmt.Errorf("upcast already registered: %s", name)
+	}
+	if existing, ok := dispatcher.upcastFrom[fromType]; ok {
+		return fmt.Errorf("upcast %s: %s is already upcast by %s", name, fromType, existing.name)
+	}
+
+	var up = &upcaster{
+		name:     name,
+		fromType: fromType,
+		toType:   toType,
+		upcast: func(from any) (any, error) {
+			v, ok := from.(From)
+			if !ok {
+				return nil, fmt.Errorf("command is %T, expected %s", from, fromType)
+			}
+			return upcast(v)
+		},
+		decode: decodeType(fromType),
+	}
+	dispatcher.upcasters[name] = up
+	dispatcher.upcastFrom[fromType] = up
+
+	return nil
+}
+
+// versionBase returns the name of the command a versioned name is a version of.
+func versionBase(name string) (string, bool) {
+	i := strings.LastIndex(name, ".v")
+	if i <= 0 || i+2 == len(name) {
+		return "", false
+	}
+
+	for _, r := range name[i+2:] {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+
+	return name[:i], true
+}
+
+// version returns the upcaster of a versioned name and the registration it is upcast to.
+// It must be called with the lock of the dispatcher held.
+func (d *Dispatcher) version(name string) (*upcaster, *registration, error) {
+	base, ok := versionBase(name)
+	if !ok {
+		return nil, nil, fmt.Errorf("command %s: %w", name, ErrNotRegistered)
+	}
+
+	reg, ok := d.executors[base]
+	if !ok {
+		return nil, nil, fmt.Errorf("command %s: %w", name, ErrNotRegistered)
+	}
+
+	up, ok := d.upcasters[name]
+	if !ok {
+		return nil, nil, &UpcastError{Name: name, Err: fmt.Errorf("%w to %s", ErrNoUpcast, base)}
+	}
+
+	return up, reg, nil
+}
+
+// registration of a command name, with the upcasters leading to it if the name is a version.
+func (d *Dispatcher) registration(name string) (*registration, []*upcaster, error) {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+
+	reg, ok := d.executors[name]
+	if ok {
+		return reg, nil, nil
+	}
+
+	up, reg, err := d.version(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chain, err := d.chain(up, reg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reg, chain, nil
+}
+
+// chain returns the upcasters from a version of a command to the command of the registration.
+// It must be called with the lock of the dispatcher held.
+func (d *Dispatcher) chain(up *upcaster, reg *registration) ([]*upcaster, error) {
+	var chain []*upcaster
+	for range len(d.upcasters) {
+		chain = append(chain, up)
+		if up.toType == reg.commandType {
+			return chain, nil
+		}
+
+		next, ok := d.upcastFrom[up.toType]
+		if !ok {
+			break
+		}
+
+		up = next
+	}
+
+	return nil, &UpcastError{Name: chain[0].name, Err: fmt.Errorf("%w from %s to %s", ErrNoUpcast, up.toType, reg.commandType)}
+}
+
+// upcast a version of a command along the chain. The lock of the dispatcher is not held,
+// so the upcasts can take their time.
+func upcast(chain []*upcaster, from any) (Command, error) {
+	for _, up := range chain {
+		to, err := up.upcast(from)
+		if err != nil {
+			return nil, &UpcastError{Name: chain[0].name, Err: err}
+		}
+
+		from = to
+	}
+
+	return from.(Command), nil
+}
+
+// decodeVersion decodes a version of a command and upcasts it along the chain.
+func decodeVersion(chain []*upcaster, decode func(v any) error) (Command, error) {
+	from, err := chain[0].decode(decode)
+	if err != nil {
+		return nil, fmt.Errorf("decode command %s: %w: %w", chain[0].name, ErrInvalidCommand, err)
+	}
+
+	return upcast(chain, from)
+}
+
+// versions returns the versioned names of the command, ordered by name.
+// It must be called with the lock of the dispatcher held.
+func (d *Dispatcher) versions(name string) []string {
+	var versions []string
+	for _, version := range slices.Sorted(maps.Keys(d.upcasters)) {
+		if base, _ := versionBase(version); base == name {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions
+}
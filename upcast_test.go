@@ -0,0 +1,187 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyuff/es"
+	commands "github.com/kyuff/es-commands"
+	"github.com/kyuff/es-commands/internal/assert"
+	"github.com/kyuff/es/storage/inmemory"
+)
+
+func TestRegisterUpcast(t *testing.T) {
+	var (
+		newDispatcher = func(t *testing.T, opts ...commands.Option) *commands.Dispatcher {
+			var storage = inmemory.New()
+			assert.NoError(t, storage.Register("account", TestEvent{}))
+			var dispatcher = commands.New(es.NewStore(storage), opts...)
+			assert.NoError(t, commands.RegisterDecider(dispatcher, "account", func(cmd TestCommand, state []string) ([]TestEvent, error) {
+				return []TestEvent{{Value: cmd.Value}}, nil
+			}, func(state []string, event TestEvent) []string {
+				return append(state, event.Value)
+			}))
+			return dispatcher
+		}
+		v1 = func(from TestCommandV1) (TestCommandV2, error) {
+			return TestCommandV2{Name: from.First + " " + from.Last}, nil
+		}
+		v2 = func(from TestCommandV2) (TestCommand, error) {
+			return TestCommand{Value: from.Name}, nil
+		}
+		capture = func(got *[]commands.Command) commands.Middleware {
+			return commands.MiddlewareFunc(func(next func(ctx context.Context, cmd commands.Command) error) func(ctx context.Context, cmd commands.Command) error {
+				return func(ctx context.Context, cmd commands.Command) error {
+					*got = append(*got, cmd)
+					return next(ctx, cmd)
+				}
+			})
+		}
+	)
+
+	t.Run("dispatch previous versions upcast before middleware", func(t *testing.T) {
+		// arrange
+		var (
+			got        []commands.Command
+			dispatcher = newDispatcher(t, commands.WithMiddlewares(capture(&got)))
+		)
+		assert.NoError(t, commands.RegisterUpcast(dispatcher, "TestCommand.v1", v1))
+		assert.NoError(t, commands.RegisterUpcast(dispatcher, "TestCommand.v2", v2))
+
+		// act
+		result, err := dispatcher.DispatchResult(t.Context(), "account-1", TestCommandV1{First: "Jane", Last: "Doe"})
+
+		// assert
+		assert.NoError(t, err)
+		if assert.Equal(t, 1, len(got)) {
+			assert.Equal(t, commands.Command(TestCommand{Value: "Jane Doe"}), got[0])
+		}
+		if assert.Equal(t, 1, len(result.Events)) {
+			assert.Equal(t, es.Content(TestEvent{Value: "Jane Doe"}), result.Events[0])
+		}
+	})
+
+	t.Run("decode versioned names", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+		assert.NoError(t, commands.RegisterUpcast(dispatcher, "TestCommand.v1", v1))
+		assert.NoError(t, commands.RegisterUpcast(dispatcher, "TestCommand.v2", v2))
+
+		// act
+		gotV1, errV1 := dispatcher.Decode("TestCommand.v1", []byte(`{"First": "Jane", "Last": "Doe"}`))
+		gotV2, errV2 := dispatcher.DecodeFunc("TestCommand.v2", func(v any) error {
+			v.(*TestCommandV2).Name = "John Doe"
+			return nil
+		})
+
+		// assert
+		assert.NoError(t, errV1)
+		assert.Equal(t, commands.Command(TestCommand{Value: "Jane Doe"}), gotV1)
+		assert.NoError(t, errV2)
+		assert.Equal(t, commands.Command(TestCommand{Value: "John Doe"}), gotV2)
+	})
+
+	t.Run("describe versioned names", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+		assert.NoError(t, commands.RegisterUpcast(dispatcher, "TestCommand.v2", v2))
+		assert.NoError(t, commands.RegisterUpcast(dispatcher, "TestCommand.v1", v1))
+
+		// act
+		desc, ok := dispatcher.Lookup("TestCommand")
+		entityType, entityOK := dispatcher.EntityType("TestCommand.v1")
+
+		// assert
+		assert.Truef(t, ok, "expected descriptor")
+		assert.EqualSlice(t, []string{"TestCommand.v1", "TestCommand.v2"}, desc.Versions)
+		assert.Truef(t, entityOK, "expected entity type of version")
+		assert.Equal(t, "account", entityType)
+	})
+
+	t.Run("fail without upcast path", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+		assert.NoError(t, commands.RegisterUpcast(dispatcher, "TestCommand.v1", v1))
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommandV1{First: "Jane"})
+
+		// assert
+		var upcastErr *commands.UpcastError
+		assert.Truef(t, errors.As(err, &upcastErr), "expected UpcastError, got %v", err)
+		assert.Truef(t, errors.Is(err, commands.ErrNoUpcast), "expected ErrNoUpcast, got %v", err)
+		assert.Truef(t, errors.Is(err, commands.ErrInvalidCommand), "expected ErrInvalidCommand, got %v", err)
+	})
+
+	t.Run("fail for versions without upcast", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+
+		// act
+		_, err := dispatcher.Decode("TestCommand.v3", []byte(`{}`))
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNoUpcast), "expected ErrNoUpcast, got %v", err)
+	})
+
+	t.Run("fail for versions of unregistered commands", func(t *testing.T) {
+		// arrange
+		var dispatcher = commands.NewDispatcher(es.NewStore(inmemory.New()))
+		assert.NoError(t, commands.RegisterUpcast(dispatcher, "TestCommand.v2", v2))
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommandV2{Name: "Jane"})
+
+		// assert
+		assert.Truef(t, errors.Is(err, commands.ErrNotRegistered), "expected ErrNotRegistered, got %v", err)
+	})
+
+	t.Run("upcast without holding the lock of the dispatcher", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+		assert.NoError(t, commands.RegisterUpcast(dispatcher, "TestCommand.v2", func(from TestCommandV2) (TestCommand, error) {
+			_ = dispatcher.Unregister("TestPointerCommand")
+			return TestCommand{Value: from.Name}, nil
+		}))
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommandV2{Name: "Jane"})
+
+		// assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail when upcast fails", func(t *testing.T) {
+		// arrange
+		var (
+			dispatcher = newDispatcher(t)
+			errUpcast  = errors.New("upcast")
+		)
+		assert.NoError(t, commands.RegisterUpcast(dispatcher, "TestCommand.v2", func(from TestCommandV2) (TestCommand, error) {
+			return TestCommand{}, errUpcast
+		}))
+
+		// act
+		err := dispatcher.Dispatch(t.Context(), "account-1", TestCommandV2{Name: "Jane"})
+
+		// assert
+		assert.Truef(t, errors.Is(err, errUpcast), "expected upcast error, got %v", err)
+		assert.Truef(t, errors.Is(err, commands.ErrInvalidCommand), "expected ErrInvalidCommand, got %v", err)
+	})
+
+	t.Run("fail on invalid registrations", func(t *testing.T) {
+		// arrange
+		var dispatcher = newDispatcher(t)
+		assert.NoError(t, commands.RegisterUpcast(dispatcher, "TestCommand.v1", v1))
+
+		// act & assert
+		assert.Error(t, commands.RegisterUpcast(dispatcher, "TestCommand", v2))
+		assert.Error(t, commands.RegisterUpcast(dispatcher, "TestCommand.vx", v2))
+		assert.Error(t, commands.RegisterUpcast(dispatcher, "TestCommand.v1", v2))
+		assert.Error(t, commands.RegisterUpcast(dispatcher, "TestCommand.v3", v1))
+		assert.Error(t, commands.RegisterUpcast(dispatcher, "TestCommand.v4", func(from TestCommand) (TestCommand, error) {
+			return from, nil
+		}))
+	})
+}